@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// AllAccounts is the -accounts value to scan every account in the organization
+const AllAccounts = "all"
+
+// DefaultRoleName is the role created in member accounts by AWS Organizations
+const DefaultRoleName = "OrganizationAccountAccessRole"
+
+// for mock testing
+type OrganizationsClient interface {
+	ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error)
+}
+
+// getAccounts returns the IDs of active accounts in the organization
+func getAccounts(client OrganizationsClient) ([]string, error) {
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+
+	accounts := make([]string, 0)
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range result.Accounts {
+			if a.Status != orgtypes.AccountStatusActive {
+				continue
+			}
+			accounts = append(accounts, aws.ToString(a.Id))
+		}
+	}
+	sort.Strings(accounts)
+	return accounts, nil
+}
+
+// assumeRoleConfig returns a copy of cfg which assumes the role in the account
+func assumeRoleConfig(cfg aws.Config, account, roleName string) aws.Config {
+	arn := fmt.Sprintf("arn:aws:iam::%s:role/%s", account, roleName)
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), arn)
+
+	assumed := cfg.Copy()
+	assumed.Credentials = aws.NewCredentialsCache(provider)
+	return assumed
+}
+
+// AccountSummary is the coverage of instances in an account
+type AccountSummary struct {
+	Account string `json:"account"`
+	Covered int    `json:"covered"`
+	Partial int    `json:"partially_covered"`
+	Not     int    `json:"uncovered"`
+}
+
+// summarizeAccounts counts covered instances per account
+func summarizeAccounts(results simurator.SimulatorResult, accounts map[string]string) []AccountSummary {
+	summaries := make(map[string]*AccountSummary)
+	get := func(instanceId *string) *AccountSummary {
+		account := accounts[aws.ToString(instanceId)]
+		if _, ok := summaries[account]; !ok {
+			summaries[account] = &AccountSummary{Account: account}
+		}
+		return summaries[account]
+	}
+	for _, i := range results.MatchInstanceResults {
+		get(i.InstanceId).Covered++
+	}
+	for _, i := range results.PartialMatchInstanceResults {
+		get(i.InstanceId).Partial++
+	}
+	for _, i := range results.UnmatchInstanceResults {
+		get(i.InstanceId).Not++
+	}
+
+	list := make([]AccountSummary, 0, len(summaries))
+	for _, s := range summaries {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Account < list[b].Account
+	})
+	return list
+}
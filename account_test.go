@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// MockOrganizationsClient returns one account per page
+type MockOrganizationsClient struct {
+	accounts []orgtypes.Account
+}
+
+func (m MockOrganizationsClient) ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error) {
+	page := 0
+	for n, a := range m.accounts {
+		if params.NextToken != nil && aws.ToString(a.Id) == *params.NextToken {
+			page = n
+		}
+	}
+	output := &organizations.ListAccountsOutput{
+		Accounts: m.accounts[page : page+1],
+	}
+	if page+1 < len(m.accounts) {
+		output.NextToken = m.accounts[page+1].Id
+	}
+	return output, nil
+}
+
+func Test_getAccounts(t *testing.T) {
+	client := MockOrganizationsClient{
+		accounts: []orgtypes.Account{
+			{Id: aws.String("333333333333"), Status: orgtypes.AccountStatusActive},
+			{Id: aws.String("111111111111"), Status: orgtypes.AccountStatusActive},
+			{Id: aws.String("222222222222"), Status: orgtypes.AccountStatusSuspended},
+		},
+	}
+	want := []string{"111111111111", "333333333333"}
+	got, err := getAccounts(client)
+	if err != nil {
+		t.Errorf("getAccounts() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getAccounts() = %v, want %v", got, want)
+	}
+}
+
+func Test_summarizeAccounts(t *testing.T) {
+	results := simurator.SimulatorResult{
+		MatchInstanceResults: []types.Instance{
+			{InstanceId: aws.String("i-000000000001")},
+			{InstanceId: aws.String("i-000000000002")},
+		},
+		PartialMatchInstanceResults: []simurator.PartialMatchInstanceResult{
+			{Instance: types.Instance{InstanceId: aws.String("i-000000000003")}},
+		},
+		UnmatchInstanceResults: []types.Instance{
+			{InstanceId: aws.String("i-000000000004")},
+		},
+	}
+	accounts := map[string]string{
+		"i-000000000001": "222222222222",
+		"i-000000000002": "111111111111",
+		"i-000000000003": "222222222222",
+		"i-000000000004": "111111111111",
+	}
+	want := []AccountSummary{
+		{Account: "111111111111", Covered: 1, Partial: 0, Not: 1},
+		{Account: "222222222222", Covered: 1, Partial: 1, Not: 0},
+	}
+	if got := summarizeAccounts(results, accounts); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeAccounts() = %v, want %v", got, want)
+	}
+}
+
+func Test_simulateRegions_multiAccount(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	clients := map[string]Ec2Client{
+		"111111111111": MockEc2Client{
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "t3.medium"},
+			},
+		},
+		"222222222222": MockEc2Client{
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "t3.medium"},
+			},
+			reservedInstances: []types.ReservedInstances{
+				{
+					ReservedInstancesId: aws.String("ri-1"),
+					InstanceCount:       aws.Int32(1),
+					InstanceType:        "t3.medium",
+					ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+				},
+			},
+		},
+	}
+	newClient := func(region, account string) Ec2Client {
+		return clients[account]
+	}
+
+	got := simulateRegions(newClient, []string{"ap-northeast-1"}, []string{"111111111111", "222222222222"})
+	if got[0].Err != nil {
+		t.Fatalf("simulateRegions() error = %v", got[0].Err)
+	}
+	wantAccounts := map[string]string{
+		"i-000000000001": "111111111111",
+		"i-000000000002": "222222222222",
+		"ri-1":           "222222222222",
+	}
+	if !reflect.DeepEqual(got[0].Accounts, wantAccounts) {
+		t.Errorf("simulateRegions() Accounts = %v, want %v", got[0].Accounts, wantAccounts)
+	}
+	// the RI is applied to the instance of the owner account
+	wantAllocations := []simurator.Allocation{
+		{ReservedInstancesId: "ri-1", InstanceId: "i-000000000002", Units: 2},
+	}
+	if !reflect.DeepEqual(got[0].Results.Allocations, wantAllocations) {
+		t.Errorf("simulateRegions() Allocations = %v, want %v", got[0].Results.Allocations, wantAllocations)
+	}
+}
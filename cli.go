@@ -1,10 +1,11 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
-	"strings"
+	"path/filepath"
 
 	"context"
 
@@ -13,6 +14,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
 )
 
@@ -21,6 +24,9 @@ const (
 	ExitCodeError
 )
 
+// Version is set at build time with -ldflags "-X main.Version=..."
+var Version = "dev"
+
 type CLI struct {
 	outStream io.Writer
 	errStream io.Writer
@@ -30,8 +36,10 @@ type CLI struct {
 type Ec2Client interface {
 	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
 	DescribeReservedInstances(ctx context.Context, params *ec2.DescribeReservedInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesOutput, error)
+	DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error)
 }
 
+// DescribeReservedInstances is not paginated (no NextToken)
 func getReservedInstances(client Ec2Client) ([]types.ReservedInstances, error) {
 	param := ec2.DescribeReservedInstancesInput{
 		Filters: []types.Filter{
@@ -49,127 +57,351 @@ func getReservedInstances(client Ec2Client) ([]types.ReservedInstances, error) {
 }
 
 func getInstances(client Ec2Client) ([]types.Instance, error) {
-	param := ec2.DescribeInstancesInput{}
-	result, err := client.DescribeInstances(context.TODO(), &param)
+	reservations, err := getReservations(client)
 	if err != nil {
 		return nil, err
 	}
+	return toInstances(reservations), nil
+}
+
+// getReservations returns the reservations of DescribeInstances as is
+func getReservations(client Ec2Client) ([]types.Reservation, error) {
+	param := ec2.DescribeInstancesInput{}
+	paginator := ec2.NewDescribeInstancesPaginator(client, &param)
+
+	reservations := make([]types.Reservation, 0)
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, result.Reservations...)
+	}
+	return reservations, nil
+}
 
+func toInstances(reservations []types.Reservation) []types.Instance {
 	instances := make([]types.Instance, 0)
-	for _, r := range result.Reservations {
+	for _, r := range reservations {
 		for _, i := range r.Instances {
-			// プラットフォームが未定義なら "Linux/UNIX" とみなす
-			if i.Platform == "" {
-				i.Platform = "Linux/UNIX"
-			}
-			// windows -> Windows (Capitalize)
-			i.Platform = types.PlatformValues(strings.Title(string(i.Platform)))
+			// Platform is only "windows" or empty (Linux/UNIX), so keep the
+			// product (e.g. "Red Hat Enterprise Linux") to match RIs with
+			i.Platform = types.PlatformValues(simurator.InstanceProduct(i))
 			instances = append(instances, i)
 		}
 	}
-	return instances, nil
+	return instances
 }
 
 func ToName(tags []types.Tag) string {
 	for _, t := range tags {
-		if *t.Key == "Name" {
-			return *t.Value
+		if aws.ToString(t.Key) == "Name" {
+			return aws.ToString(t.Value)
 		}
 	}
 	return ""
 }
 
+// ToState returns the state of the instance, or "" when it is unknown.
+func ToState(i types.Instance) types.InstanceStateName {
+	if i.State == nil {
+		return ""
+	}
+	return i.State.Name
+}
+
+// ToScope returns the Availability Zone of a zonal RI, or "Region".
+func ToScope(ri types.ReservedInstances) string {
+	if ri.Scope == types.ScopeAvailabilityZone && ri.AvailabilityZone != nil {
+		return *ri.AvailabilityZone
+	}
+	return string(types.ScopeRegional)
+}
+
+const usage = `Usage:
+  %[1]s [options]
+  %[1]s snapshot -o file
+  %[1]s recommend [options]
+  %[1]s timeline [options]
+  %[1]s expiring [options]
+
+Simulate how Reserved Instances are applied to running EC2 instances.
+
+Options:
+`
+
 func (cli *CLI) Run(args []string) int {
-	cfg, err := config.LoadDefaultConfig(context.Background(),
-		config.WithAssumeRoleCredentialOptions(func(options *stscreds.AssumeRoleOptions) {
-			options.TokenProvider = func() (string, error) {
-				return stscreds.StdinTokenProvider()
-			}
+	if len(args) > 1 && args[1] == "snapshot" {
+		return cli.runSnapshot(args[1:])
+	}
+	if len(args) > 1 && args[1] == "recommend" {
+		return cli.runRecommend(args[1:])
+	}
+	if len(args) > 1 && args[1] == "timeline" {
+		return cli.runTimeline(args[1:])
+	}
+	if len(args) > 1 && args[1] == "expiring" {
+		return cli.runExpiring(args[1:])
+	}
 
-		}),
-	)
-	if err != nil {
-		log.Fatal(err)
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(cli.errStream)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), usage, filepath.Base(args[0]))
+		flags.PrintDefaults()
+	}
+	var input inputFlags
+	input.register(flags)
+	output := flags.String("output", "text", "output format: text, json, csv, tsv, markdown or html")
+	outputDir := flags.String("output-dir", "", "write each csv/tsv section to a file in `dir` instead of one table to stdout")
+	showAllocations := flags.Bool("allocations", false, "print which RI covers which instance")
+	sortFlag := flags.String("sort", DefaultSort, "comma-separated `keys` to sort instances and RIs by: state, platform, type, tenancy, name, id, az, launch-time or tag:<Key>; prefix a key with - to sort in descending order")
+	stateFlag := flags.String("state", "", "comma-separated instance `states` to report, e.g. running,stopped (default: all)")
+	var addRIs HypotheticalRIs
+	flags.Var(&addRIs, "add-ri", "add a hypothetical RI `count:type:product[:az or region]`, e.g. 3:m5.large:Linux/UNIX (repeatable)")
+	scenarioFile := flags.String("scenario", "", "add hypothetical RIs from a JSON `file` and compare with the current RIs")
+	showVersion := flags.Bool("version", false, "print the version and exit")
+	if code, ok := parseFlags(flags, args[1:]); !ok {
+		return code
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(cli.errStream, "unknown command: %s\n", flags.Arg(0))
+		flags.Usage()
 		return ExitCodeError
 	}
+	if *showVersion {
+		fmt.Fprintf(cli.outStream, "%s version %s\n", filepath.Base(args[0]), Version)
+		return ExitCodeOK
+	}
 
-	client := ec2.NewFromConfig(cfg)
-
-	instances, err := getInstances(client)
+	renderer, err := NewRenderer(*output, RenderOptions{
+		ShowAllocations: *showAllocations,
+		OutputDir:       *outputDir,
+	})
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(cli.errStream, err.Error())
 		return ExitCodeError
 	}
-	ri_instances, err := getReservedInstances(client)
+	if *outputDir != "" && *output != "csv" && *output != "tsv" {
+		fmt.Fprintln(cli.errStream, "-output-dir requires -output csv or tsv")
+		return ExitCodeError
+	}
+	if err := input.validate(); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	sorter, err := parseSort(*sortFlag)
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(cli.errStream, err.Error())
 		return ExitCodeError
 	}
-
-	sim := &simurator.Simulator{
-		Instances:         instances,
-		ReservedInstances: ri_instances,
+	states, err := parseStates(*stateFlag)
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	whatIfRIs := []HypotheticalRI(addRIs)
+	if *scenarioFile != "" {
+		ris, err := loadScenario(*scenarioFile)
+		if err != nil {
+			fmt.Fprintln(cli.errStream, err.Error())
+			return ExitCodeError
+		}
+		whatIfRIs = append(whatIfRIs, ris...)
 	}
-	results, err := sim.Simulate()
+
+	regionResults, err := input.simulate()
 	if err != nil {
-		fmt.Println(err.Error())
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+
+	if err := checkWhatIfRegions(whatIfRIs, regionResults); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	if len(whatIfRIs) > 0 {
+		for n, r := range regionResults {
+			regionResults[n] = simulateWhatIf(r, whatIfRIs)
+		}
+	}
+
+	exitCode := cli.reportErrors(regionResults)
+	for n, r := range regionResults {
+		if r.Err != nil {
+			continue
+		}
+		regionResults[n].States = states
+		sorter.SortResults(r.Results)
+	}
+
+	if err := renderer.Render(cli.outStream, regionResults); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
 		return ExitCodeError
 	}
+	return exitCode
+}
+
+// parseFlags parses the flags of a command. If it returns false, the command
+// exits with the code: ExitCodeOK for -help, ExitCodeError for invalid flags.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitCodeOK, false
+		}
+		return ExitCodeError, false
+	}
+	return ExitCodeOK, true
+}
+
+// validateOutput checks -output of the subcommands writing text or json
+func validateOutput(output string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("invalid -output: %s (want text or json)", output)
+	}
+	return nil
+}
 
-	platform := func(p1, p2 types.Instance) bool {
-		if p1.Platform != p2.Platform {
-			return p1.Platform == ""
+// reportErrors writes the error of each failed region to errStream
+// and returns the exit code
+func (cli *CLI) reportErrors(regionResults []RegionResult) int {
+	exitCode := ExitCodeOK
+	for _, r := range regionResults {
+		if r.Err == nil {
+			continue
+		}
+		if r.Region != "" {
+			fmt.Fprintf(cli.errStream, "%s: %s\n", r.Region, r.Err.Error())
 		} else {
-			return true
+			fmt.Fprintln(cli.errStream, r.Err.Error())
 		}
+		exitCode = ExitCodeError
 	}
+	return exitCode
+}
+
+// inputFlags select where instances and RIs are read from:
+// AWS (default), files of the AWS CLI, or a snapshot
+type inputFlags struct {
+	aws           AWSOptions
+	instancesFile string
+	reservedFile  string
+	snapshotFile  string
+}
+
+func (in *inputFlags) register(flags *flag.FlagSet) {
+	in.aws.register(flags)
+	flags.StringVar(&in.aws.Regions, "regions", "", `comma-separated regions to scan, or "all" for every enabled region`)
+	flags.StringVar(&in.aws.Accounts, "accounts", "", `comma-separated accounts to pool, or "all" for every account in the organization`)
+	flags.StringVar(&in.aws.RoleName, "role-name", DefaultRoleName, "role to assume in each account of -accounts")
+	flags.StringVar(&in.instancesFile, "instances-file", "", "read instances from a `file` of 'aws ec2 describe-instances --output json' instead of AWS")
+	flags.StringVar(&in.reservedFile, "reserved-file", "", "read RIs from a `file` of 'aws ec2 describe-reserved-instances --output json' instead of AWS")
+	flags.StringVar(&in.snapshotFile, "snapshot", "", "read instances and RIs from a `file` written by the snapshot command")
+}
 
-	instancetype := func(p1, p2 types.Instance) bool {
-		return p1.InstanceType < p2.InstanceType
+func (in *inputFlags) validate() error {
+	if in.snapshotFile != "" && (in.instancesFile != "" || in.reservedFile != "") {
+		return errors.New("-snapshot cannot be used with -instances-file or -reserved-file")
 	}
+	return nil
+}
 
-	name := func(p1, p2 types.Instance) bool {
-		return ToName(p1.Tags) < ToName(p2.Tags)
+// simulate reads instances and RIs and simulates each region. The error is
+// returned when nothing can be read, e.g. without AWS credentials; errors of
+// a region are in its RegionResult.
+func (in *inputFlags) simulate() ([]RegionResult, error) {
+	if in.snapshotFile != "" {
+		return []RegionResult{simulateSnapshot(in.snapshotFile)}, nil
+	}
+	if in.instancesFile != "" || in.reservedFile != "" {
+		return []RegionResult{simulateFiles(in.instancesFile, in.reservedFile)}, nil
 	}
+	return simulateAWS(in.aws)
+}
 
-	state := func(p1, p2 types.Instance) bool {
-		return *p1.State.Code < *p2.State.Code
+// AWSOptions configures how instances and RIs are fetched from AWS
+type AWSOptions struct {
+	// named profile of the shared config
+	Profile string
+	// region of the AWS config, overridden by Regions
+	Region   string
+	Regions  string
+	Accounts string
+	RoleName string
+}
+
+// register defines the flags of the AWS config, shared by every command
+// reading from AWS
+func (opts *AWSOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&opts.Profile, "profile", "", "use a named `profile` of the shared AWS config")
+	flags.StringVar(&opts.Region, "region", "", "AWS `region` (default: the region of the AWS config)")
+}
+
+// loadConfig loads the AWS config of the profile and region
+func (opts AWSOptions) loadConfig() (aws.Config, error) {
+	optFns := []func(*config.LoadOptions) error{
+		config.WithAssumeRoleCredentialOptions(func(options *stscreds.AssumeRoleOptions) {
+			options.TokenProvider = func() (string, error) {
+				return stscreds.StdinTokenProvider()
+			}
+
+		}),
+	}
+	if opts.Profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(opts.Profile))
+	}
+	if opts.Region != "" {
+		optFns = append(optFns, config.WithRegion(opts.Region))
 	}
+	return config.LoadDefaultConfig(context.Background(), optFns...)
+}
 
-	fmt.Println("=== RI covered instances ===")
-	OrderBy(state, platform, instancetype, name).Sort(results.MatchInstanceResults)
-	for _, i := range results.MatchInstanceResults {
-		fmt.Printf("%-20s %-12s %-10s %-20s %-s\n",
-			*i.InstanceId,
-			i.InstanceType,
-			i.Platform,
-			ToName(i.Tags),
-			i.State.Name)
+// simulateAWS fetches instances and RIs from AWS and simulates each region
+func simulateAWS(opts AWSOptions) ([]RegionResult, error) {
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println()
 
-	fmt.Println("=== RI *NOT* covered instances ===")
-	OrderBy(state, platform, instancetype, name).Sort(results.UnmatchInstanceResults)
-	for _, i := range results.UnmatchInstanceResults {
-		fmt.Printf("%-20s %-12s %-10s %-20s %-s\n",
-			*i.InstanceId,
-			i.InstanceType,
-			i.Platform,
-			ToName(i.Tags),
-			i.State.Name)
+	regions := []string{cfg.Region}
+	if opts.Regions == AllRegions {
+		regions, err = getRegions(ec2.NewFromConfig(cfg))
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.Regions != "" {
+		regions = parseList(opts.Regions)
 	}
-	fmt.Println()
 
-	fmt.Println("=== Purchased but not applied RI ===")
-	for _, ri := range results.UnmatchReservedInstanceResults {
-		fmt.Printf("%20s %-12s %-10s %-12s %3d %v\n",
-			"",
-			ri.InstanceType,
-			ri.ProductDescription,
-			ri.OfferingType,
-			*ri.InstanceCount,
-			ri.End)
+	accounts := []string{""}
+	configs := map[string]aws.Config{"": cfg}
+	if opts.Accounts != "" {
+		identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return nil, err
+		}
+		if opts.Accounts == AllAccounts {
+			accounts, err = getAccounts(organizations.NewFromConfig(cfg))
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			accounts = parseList(opts.Accounts)
+		}
+		for _, account := range accounts {
+			if account == aws.ToString(identity.Account) {
+				// no need to assume a role in the caller's own account
+				configs[account] = cfg
+			} else {
+				configs[account] = assumeRoleConfig(cfg, account, opts.RoleName)
+			}
+		}
 	}
 
-	return ExitCodeOK
+	newClient := func(region, account string) Ec2Client {
+		return ec2.NewFromConfig(configs[account], func(o *ec2.Options) {
+			o.Region = region
+		})
+	}
+	return simulateRegions(newClient, regions, accounts), nil
 }
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"flag"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -56,12 +62,76 @@ func TestToName(t *testing.T) {
 	}
 }
 
+func TestToState(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance types.Instance
+		want     types.InstanceStateName
+	}{
+		{
+			name:     "State",
+			instance: types.Instance{State: &types.InstanceState{Name: types.InstanceStateNameStopped}},
+			want:     types.InstanceStateNameStopped,
+		},
+		{
+			name:     "No State",
+			instance: types.Instance{},
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToState(tt.instance); got != tt.want {
+				t.Errorf("ToState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToScope(t *testing.T) {
+	tests := []struct {
+		name string
+		ri   types.ReservedInstances
+		want string
+	}{
+		{
+			ri: types.ReservedInstances{
+				Scope:            types.ScopeAvailabilityZone,
+				AvailabilityZone: aws.String("ap-northeast-1a"),
+			},
+			want: "ap-northeast-1a",
+		},
+		{
+			ri: types.ReservedInstances{
+				Scope: types.ScopeRegional,
+			},
+			want: "Region",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToScope(tt.ri); got != tt.want {
+				t.Errorf("ToScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // see
 // https://aws.github.io/aws-sdk-go-v2/docs/unit-testing/
 // https://qiita.com/tenntenn/items/eac962a49c56b2b15ee8
 type MockEc2Client struct {
 	reservedInstances []types.ReservedInstances
 	instances         []types.Instance
+	regions           []string
+}
+
+func (m MockEc2Client) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	output := &ec2.DescribeRegionsOutput{}
+	for _, r := range m.regions {
+		output.Regions = append(output.Regions, types.Region{RegionName: aws.String(r)})
+	}
+	return output, nil
 }
 
 func (m MockEc2Client) DescribeReservedInstances(ctx context.Context, params *ec2.DescribeReservedInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesOutput, error) {
@@ -90,6 +160,37 @@ func (m MockEc2Client) DescribeInstances(ctx context.Context, params *ec2.Descri
 	}, nil
 }
 
+// MockPagedEc2Client returns instances one page at a time
+type MockPagedEc2Client struct {
+	MockEc2Client
+	pages [][]types.Instance
+}
+
+func (m MockPagedEc2Client) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	page := 0
+	if params.NextToken != nil {
+		n, err := strconv.Atoi(*params.NextToken)
+		if err != nil {
+			return nil, err
+		}
+		page = n
+	}
+	if page >= len(m.pages) {
+		return nil, errors.New("invalid NextToken")
+	}
+	output := &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{
+				Instances: m.pages[page],
+			},
+		},
+	}
+	if page+1 < len(m.pages) {
+		output.NextToken = aws.String(strconv.Itoa(page + 1))
+	}
+	return output, nil
+}
+
 func Test_getReservedInstances(t *testing.T) {
 	type args struct {
 		client Ec2Client
@@ -198,6 +299,51 @@ func Test_getInstances(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Multiple pages",
+			args: args{
+				client: MockPagedEc2Client{
+					pages: [][]types.Instance{
+						{
+							{
+								InstanceId:   aws.String("i-000000000001"),
+								InstanceType: "t3.medium",
+							},
+						},
+						{
+							{
+								InstanceId:   aws.String("i-000000000002"),
+								InstanceType: "t3.medium",
+								Platform:     "windows",
+							},
+						},
+						{
+							{
+								InstanceId:   aws.String("i-000000000003"),
+								InstanceType: "t3.large",
+							},
+						},
+					},
+				},
+			},
+			want: []types.Instance{
+				{
+					InstanceId:   aws.String("i-000000000001"),
+					InstanceType: "t3.medium",
+					Platform:     "Linux/UNIX",
+				},
+				{
+					InstanceId:   aws.String("i-000000000002"),
+					InstanceType: "t3.medium",
+					Platform:     "Windows",
+				},
+				{
+					InstanceId:   aws.String("i-000000000003"),
+					InstanceType: "t3.large",
+					Platform:     "Linux/UNIX",
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -212,3 +358,260 @@ func Test_getInstances(t *testing.T) {
 		})
 	}
 }
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestCLI_Run(t *testing.T) {
+	files := []string{
+		"-instances-file", "testdata/describe-instances.json",
+		"-reserved-file", "testdata/describe-reserved-instances.json",
+	}
+	tests := []struct {
+		name     string
+		args     []string
+		golden   string
+		wantErr  string
+		wantCode int
+	}{
+		{
+			name:   "text",
+			args:   files,
+			golden: "report.txt.golden",
+		},
+		{
+			name:   "text with allocations",
+			args:   append([]string{"-allocations"}, files...),
+			golden: "report_allocations.txt.golden",
+		},
+		{
+			name:   "json",
+			args:   append([]string{"-output", "json"}, files...),
+			golden: "report.json.golden",
+		},
+		{
+			name:   "csv",
+			args:   append([]string{"-output", "csv"}, files...),
+			golden: "report.csv.golden",
+		},
+		{
+			name:   "markdown",
+			args:   append([]string{"-output", "markdown"}, files...),
+			golden: "report.md.golden",
+		},
+		{
+			name:     "allocations with csv",
+			args:     append([]string{"-output", "csv", "-allocations"}, files...),
+			wantErr:  "-allocations cannot be used with -output csv\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:     "invalid output",
+			args:     append([]string{"-output", "xml"}, files...),
+			wantErr:  "invalid -output: xml\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:   "state filter",
+			args:   append([]string{"-state", "running", "-sort", "name"}, files...),
+			golden: "report_running.txt.golden",
+		},
+		{
+			name:   "state filter keeps statistics",
+			args:   append([]string{"-state", "stopped"}, files...),
+			golden: "report_stopped.txt.golden",
+		},
+		{
+			name:   "state filter with what-if",
+			args:   append([]string{"-state", "stopped", "-add-ri", "1:m5.large:Linux/UNIX", "-output", "json"}, files...),
+			golden: "report_stopped_whatif.json.golden",
+		},
+		{
+			name:   "sort descending",
+			args:   append([]string{"-sort", "-name"}, files...),
+			golden: "report_sort.txt.golden",
+		},
+		{
+			name:   "what-if",
+			args:   append([]string{"-add-ri", "1:m5.large:Linux/UNIX", "-scenario", "testdata/scenario.json"}, files...),
+			golden: "report_whatif.txt.golden",
+		},
+		{
+			name:     "invalid what-if RI",
+			args:     append([]string{"-add-ri", "m5.large"}, files...),
+			wantErr:  "invalid value \"m5.large\" for flag -add-ri: invalid RI \"m5.large\": want count:type:product[:az or region]\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:   "version",
+			args:   []string{"-version"},
+			golden: "version.golden",
+		},
+		{
+			name:     "invalid sort",
+			args:     append([]string{"-sort", "size"}, files...),
+			wantErr:  "invalid -sort key: size\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:     "invalid state",
+			args:     append([]string{"-state", "sleeping"}, files...),
+			wantErr:  "invalid -state: sleeping\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:     "output-dir without csv",
+			args:     append([]string{"-output-dir", "out"}, files...),
+			wantErr:  "-output-dir requires -output csv or tsv\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:     "snapshot with files",
+			args:     append([]string{"-snapshot", "snapshot.json"}, files...),
+			wantErr:  "-snapshot cannot be used with -instances-file or -reserved-file\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:   "recommend",
+			args:   append([]string{"recommend"}, files...),
+			golden: "recommend.txt.golden",
+		},
+		{
+			name:   "recommend json zonal",
+			args:   append([]string{"recommend", "-zonal", "-output", "json"}, files...),
+			golden: "recommend.json.golden",
+		},
+		{
+			name:     "recommend invalid target",
+			args:     append([]string{"recommend", "-target", "120"}, files...),
+			wantErr:  "invalid -target: 120 (want 0 < target <= 100)\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:   "timeline",
+			args:   append([]string{"timeline", "-start", "2022-07-01", "-months", "9"}, files...),
+			golden: "timeline.txt.golden",
+		},
+		{
+			name:     "timeline invalid interval",
+			args:     append([]string{"timeline", "-interval", "1y"}, files...),
+			wantErr:  "invalid -interval: 1y (want e.g. 1m, 2w or 10d)\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:   "expiring",
+			args:   append([]string{"expiring", "-start", "2022-12-01", "--within", "3m"}, files...),
+			golden: "expiring.txt.golden",
+		},
+		{
+			name:   "expiring json",
+			args:   append([]string{"expiring", "-start", "2022-12-01", "-output", "json"}, files...),
+			golden: "expiring.json.golden",
+		},
+		{
+			name:     "expiring invalid within",
+			args:     append([]string{"expiring", "-within", "90"}, files...),
+			wantErr:  "invalid -within: 90 (want e.g. 1m, 2w or 10d)\n",
+			wantCode: ExitCodeError,
+		},
+		{
+			name:   "missing fields",
+			args:   []string{"-instances-file", "testdata/describe-instances-minimal.json", "-reserved-file", "testdata/describe-reserved-instances-minimal.json", "-allocations"},
+			golden: "report_minimal.txt.golden",
+		},
+		{
+			name:   "missing fields json",
+			args:   []string{"-instances-file", "testdata/describe-instances-minimal.json", "-reserved-file", "testdata/describe-reserved-instances-minimal.json", "-output", "json"},
+			golden: "report_minimal.json.golden",
+		},
+		{
+			name:     "missing file",
+			args:     []string{"-instances-file", "testdata/missing.json"},
+			wantErr:  "open testdata/missing.json: no such file or directory\n",
+			wantCode: ExitCodeError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var outStream, errStream bytes.Buffer
+			cli := &CLI{outStream: &outStream, errStream: &errStream}
+			code := cli.Run(append([]string{"gori-simulator"}, tt.args...))
+			if code != tt.wantCode {
+				t.Errorf("Run() = %v, want %v (stderr: %s)", code, tt.wantCode, errStream.String())
+			}
+			// flag errors are followed by the usage
+			if got := errStream.String(); got != tt.wantErr && (tt.wantErr == "" || !strings.HasPrefix(got, tt.wantErr+"Usage:")) {
+				t.Errorf("Run() stderr = %q, want %q", got, tt.wantErr)
+			}
+			if tt.golden == "" {
+				if outStream.Len() != 0 {
+					t.Errorf("Run() stdout = %q, want empty", outStream.String())
+				}
+				return
+			}
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(golden, outStream.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if outStream.String() != string(want) {
+				t.Errorf("Run() stdout differs from %s\ngot:\n%s\nwant:\n%s", golden, outStream.String(), want)
+			}
+		})
+	}
+}
+
+func TestCLI_Run_help(t *testing.T) {
+	var outStream, errStream bytes.Buffer
+	cli := &CLI{outStream: &outStream, errStream: &errStream}
+	if code := cli.Run([]string{"gori-simulator", "-help"}); code != ExitCodeOK {
+		t.Errorf("Run() = %v, want %v", code, ExitCodeOK)
+	}
+	for _, want := range []string{"Usage:", "gori-simulator snapshot -o file", "-profile profile", "-sort keys"} {
+		if !strings.Contains(errStream.String(), want) {
+			t.Errorf("usage does not contain %q\n%s", want, errStream.String())
+		}
+	}
+
+	errStream.Reset()
+	if code := cli.Run([]string{"gori-simulator", "snapshot", "-h"}); code != ExitCodeOK {
+		t.Errorf("Run() = %v, want %v", code, ExitCodeOK)
+	}
+	for _, want := range []string{"-profile profile", "-region region", "-o file"} {
+		if !strings.Contains(errStream.String(), want) {
+			t.Errorf("snapshot usage does not contain %q\n%s", want, errStream.String())
+		}
+	}
+
+	errStream.Reset()
+	if code := cli.Run([]string{"gori-simulator", "-unknown"}); code != ExitCodeError {
+		t.Errorf("Run() = %v, want %v", code, ExitCodeError)
+	}
+	if !strings.Contains(errStream.String(), "flag provided but not defined: -unknown") {
+		t.Errorf("Run() stderr = %q", errStream.String())
+	}
+}
+
+func Test_toInstances(t *testing.T) {
+	reservations := []types.Reservation{
+		{
+			Instances: []types.Instance{
+				{InstanceId: aws.String("i-linux")},
+				{InstanceId: aws.String("i-windows"), Platform: types.PlatformValuesWindows},
+				{InstanceId: aws.String("i-rhel"), PlatformDetails: aws.String("Red Hat Enterprise Linux"), UsageOperation: aws.String("RunInstances:0010")},
+				{InstanceId: aws.String("i-sql"), Platform: types.PlatformValuesWindows, UsageOperation: aws.String("RunInstances:0006")},
+			},
+		},
+	}
+	want := []types.PlatformValues{"Linux/UNIX", "Windows", "Red Hat Enterprise Linux", "Windows with SQL Server Standard"}
+	got := toInstances(reservations)
+	for n, platform := range want {
+		if got[n].Platform != platform {
+			t.Errorf("toInstances()[%d].Platform = %v, want %v", n, got[n].Platform, platform)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// ExpiringInstance is an instance covered by an expiring RI
+type ExpiringInstance struct {
+	JSONInstance
+	// units covered by the expiring RI, falling to on-demand at its end
+	AllocatedUnits float64 `json:"allocated_units"`
+}
+
+// ExpiringReservedInstance is an active RI ending within the window
+type ExpiringReservedInstance struct {
+	JSONReservedInstance
+	DaysLeft         int                `json:"days_left"`
+	CoveredInstances []ExpiringInstance `json:"covered_instances"`
+}
+
+type ExpiringRegionReport struct {
+	Region            string                     `json:"region"`
+	Error             string                     `json:"error,omitempty"`
+	ReservedInstances []ExpiringReservedInstance `json:"reserved_instances"`
+}
+
+// ExpiringSchemaVersion is incremented on incompatible changes of ExpiringReport
+const ExpiringSchemaVersion = 1
+
+// ExpiringReport is the document of "expiring -output json"
+type ExpiringReport struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Start         time.Time              `json:"start"`
+	End           time.Time              `json:"end"`
+	Regions       []ExpiringRegionReport `json:"regions"`
+}
+
+// expiringRegion lists the RIs of the region ending after start and
+// until end, with the instances they cover, in order of the end
+func expiringRegion(r RegionResult, start, end time.Time) ExpiringRegionReport {
+	report := ExpiringRegionReport{Region: r.Region, ReservedInstances: []ExpiringReservedInstance{}}
+	if r.Err != nil {
+		report.Error = r.Err.Error()
+		return report
+	}
+
+	instances := map[string]types.Instance{}
+	covered := map[string]float64{}
+	if r.Simulator != nil {
+		for _, i := range r.Simulator.Instances {
+			instances[aws.ToString(i.InstanceId)] = i
+		}
+	}
+	for _, a := range r.Results.Allocations {
+		covered[a.InstanceId] += a.Units
+	}
+
+	for _, ri := range r.Results.ReservedInstanceResults {
+		if ri.End == nil || !ri.End.After(start) || ri.End.After(end) {
+			continue
+		}
+		if ri.State != "" && ri.State != types.ReservedInstanceStateActive {
+			continue
+		}
+		expiring := ExpiringReservedInstance{
+			JSONReservedInstance: toJSONReservedInstance(ri),
+			DaysLeft:             int(ri.End.Sub(start).Hours() / 24),
+			CoveredInstances:     []ExpiringInstance{},
+		}
+		for _, a := range r.Results.AllocationsOf(aws.ToString(ri.ReservedInstancesId)) {
+			i, ok := instances[a.InstanceId]
+			if !ok {
+				i = types.Instance{InstanceId: aws.String(a.InstanceId)}
+			}
+			expiring.CoveredInstances = append(expiring.CoveredInstances, ExpiringInstance{
+				JSONInstance:   toJSONInstance(i, simurator.Units(i.InstanceType), covered[a.InstanceId]),
+				AllocatedUnits: a.Units,
+			})
+		}
+		report.ReservedInstances = append(report.ReservedInstances, expiring)
+	}
+	sort.SliceStable(report.ReservedInstances, func(a, b int) bool {
+		return report.ReservedInstances[a].End.Before(*report.ReservedInstances[b].End)
+	})
+	return report
+}
+
+func writeExpiringText(w io.Writer, report ExpiringReport, within period) {
+	first := true
+	for _, region := range report.Regions {
+		if region.Error != "" {
+			// the error itself is reported on errStream
+			continue
+		}
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+
+		fmt.Fprintf(w, "=== RIs expiring within %s: %s ===\n", within, regionName(region.Region))
+		if len(region.ReservedInstances) == 0 {
+			fmt.Fprintln(w, "None")
+		}
+		for _, ri := range region.ReservedInstances {
+			fmt.Fprintf(w, "%s (%d days)  %s  %dx %s %s %s %s %s\n",
+				ri.End.Format("2006-01-02"),
+				ri.DaysLeft,
+				ri.ReservedInstancesId,
+				ri.InstanceCount,
+				ri.InstanceType,
+				ri.ProductDescription,
+				ri.Scope,
+				ri.Tenancy,
+				ri.OfferingClass)
+			if len(ri.CoveredInstances) == 0 {
+				fmt.Fprintln(w, "    (no instances covered)")
+			}
+			for _, i := range ri.CoveredInstances {
+				fmt.Fprintf(w, "    %-20s %-12s %-20s %-10s %6.2f/%.2f\n",
+					i.InstanceId,
+					i.InstanceType,
+					i.Name,
+					i.State,
+					i.AllocatedUnits,
+					i.Units)
+			}
+		}
+	}
+}
+
+// runExpiring is the "expiring" subcommand
+func (cli *CLI) runExpiring(args []string) int {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(cli.errStream)
+	var input inputFlags
+	input.register(flags)
+	withinFlag := flags.String("within", "90d", "list RIs ending within the `period`: days (90d), weeks (12w) or months (3m)")
+	startFlag := flags.String("start", "", "start of the period as `YYYY-MM-DD` (default: today)")
+	output := flags.String("output", "text", "output `format`: text or json")
+	if code, ok := parseFlags(flags, args[1:]); !ok {
+		return code
+	}
+
+	within, err := parsePeriod("within", *withinFlag)
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	start, err := parseDate("start", *startFlag, time.Now())
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	if err := validateOutput(*output); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	if err := input.validate(); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+
+	regionResults, err := input.simulate()
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	exitCode := cli.reportErrors(regionResults)
+
+	report := ExpiringReport{
+		SchemaVersion: ExpiringSchemaVersion,
+		Start:         start,
+		End:           within.after(start, 1),
+		Regions:       []ExpiringRegionReport{},
+	}
+	for _, r := range regionResults {
+		report.Regions = append(report.Regions, expiringRegion(r, report.Start, report.End))
+	}
+
+	if *output == "json" {
+		if err := writeJSON(cli.outStream, report); err != nil {
+			fmt.Fprintln(cli.errStream, err.Error())
+			return ExitCodeError
+		}
+	} else {
+		writeExpiringText(cli.outStream, report, within)
+	}
+	return exitCode
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+func Test_expiringRegion(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	ri := func(id string, t types.InstanceType, end time.Time) types.ReservedInstances {
+		return types.ReservedInstances{
+			ReservedInstancesId: aws.String(id),
+			InstanceType:        t,
+			InstanceCount:       aws.Int32(1),
+			ProductDescription:  "Linux/UNIX",
+			Scope:               types.ScopeRegional,
+			State:               types.ReservedInstanceStateActive,
+			End:                 aws.Time(end),
+		}
+	}
+	sim := &simurator.Simulator{
+		Instances: []types.Instance{
+			{InstanceId: aws.String("i-1"), InstanceType: "m5.large", State: running},
+			{InstanceId: aws.String("i-2"), InstanceType: "c5.large", State: running},
+		},
+		ReservedInstances: []types.ReservedInstances{
+			ri("later", "m5.large", time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)),
+			ri("sooner", "c5.large", time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)),
+			ri("expired", "r5.large", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)),
+			ri("outside", "r5.large", time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	results, err := sim.Simulate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	report := expiringRegion(RegionResult{Results: results, Simulator: sim}, start, start.AddDate(0, 0, 90))
+
+	type expiring struct {
+		id        string
+		daysLeft  int
+		instances []string
+	}
+	var got []expiring
+	for _, ri := range report.ReservedInstances {
+		e := expiring{id: ri.ReservedInstancesId, daysLeft: ri.DaysLeft}
+		for _, i := range ri.CoveredInstances {
+			e.instances = append(e.instances, i.InstanceId)
+		}
+		got = append(got, e)
+	}
+	// "expired" ends at start and "outside" after the window
+	want := []expiring{
+		{id: "sooner", daysLeft: 31, instances: []string{"i-2"}},
+		{id: "later", daysLeft: 62, instances: []string{"i-1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expiringRegion() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+// Offline input from
+//   aws ec2 describe-instances --output json
+//   aws ec2 describe-reserved-instances --output json
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+func readInstances(r io.Reader) ([]types.Instance, error) {
+	var output ec2.DescribeInstancesOutput
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		return nil, err
+	}
+	return toInstances(output.Reservations), nil
+}
+
+// readReservedInstances returns active RIs, as getReservedInstances does
+func readReservedInstances(r io.Reader) ([]types.ReservedInstances, error) {
+	var output ec2.DescribeReservedInstancesOutput
+	if err := json.NewDecoder(r).Decode(&output); err != nil {
+		return nil, err
+	}
+	ri_instances := make([]types.ReservedInstances, 0)
+	for _, ri := range output.ReservedInstances {
+		if ri.State == types.ReservedInstanceStateActive {
+			ri_instances = append(ri_instances, ri)
+		}
+	}
+	return ri_instances, nil
+}
+
+func loadInstances(path string) ([]types.Instance, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readInstances(f)
+}
+
+func loadReservedInstances(path string) ([]types.ReservedInstances, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readReservedInstances(f)
+}
+
+// simulateFiles simulates instances and RIs read from files.
+// An empty path means no instances or no RIs.
+func simulateFiles(instancesFile, reservedFile string) RegionResult {
+	sim := &simurator.Simulator{}
+	result := RegionResult{}
+	if instancesFile != "" {
+		sim.Instances, result.Err = loadInstances(instancesFile)
+		if result.Err != nil {
+			return result
+		}
+	}
+	if reservedFile != "" {
+		sim.ReservedInstances, result.Err = loadReservedInstances(reservedFile)
+		if result.Err != nil {
+			return result
+		}
+	}
+	result.Simulator = sim
+	result.Results, result.Err = sim.Simulate()
+	return result
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func Test_loadInstances(t *testing.T) {
+	got, err := loadInstances("testdata/describe-instances.json")
+	if err != nil {
+		t.Fatalf("loadInstances() error = %v", err)
+	}
+	var ids []string
+	var platforms []types.PlatformValues
+	for _, i := range got {
+		ids = append(ids, *i.InstanceId)
+		platforms = append(platforms, i.Platform)
+	}
+	wantIds := []string{"i-000000000001", "i-000000000002", "i-000000000003", "i-000000000004"}
+	if !reflect.DeepEqual(ids, wantIds) {
+		t.Errorf("loadInstances() InstanceId = %v, want %v", ids, wantIds)
+	}
+	wantPlatforms := []types.PlatformValues{"Linux/UNIX", "Linux/UNIX", "Windows", "Linux/UNIX"}
+	if !reflect.DeepEqual(platforms, wantPlatforms) {
+		t.Errorf("loadInstances() Platform = %v, want %v", platforms, wantPlatforms)
+	}
+}
+
+func Test_loadReservedInstances(t *testing.T) {
+	got, err := loadReservedInstances("testdata/describe-reserved-instances.json")
+	if err != nil {
+		t.Fatalf("loadReservedInstances() error = %v", err)
+	}
+	// retired RIs are dropped
+	if len(got) != 2 {
+		t.Fatalf("loadReservedInstances() returns %v RIs, want %v", len(got), 2)
+	}
+	if got[1].Scope != types.ScopeAvailabilityZone || *got[1].AvailabilityZone != "ap-northeast-1a" {
+		t.Errorf("loadReservedInstances()[1] = %v %v, want zonal RI in ap-northeast-1a", got[1].Scope, *got[1].AvailabilityZone)
+	}
+}
+
+func Test_readInstances_invalid(t *testing.T) {
+	if _, err := readInstances(strings.NewReader("{")); err == nil {
+		t.Errorf("readInstances() error = nil, want error")
+	}
+	if _, err := readReservedInstances(strings.NewReader("[]")); err == nil {
+		t.Errorf("readReservedInstances() error = nil, want error")
+	}
+}
+
+func Test_simulateFiles(t *testing.T) {
+	got := simulateFiles("testdata/describe-instances.json", "testdata/describe-reserved-instances.json")
+	if got.Err != nil {
+		t.Fatalf("simulateFiles() error = %v", got.Err)
+	}
+	if n := len(got.Results.MatchInstanceResults); n != 1 {
+		t.Errorf("simulateFiles() covered %v instances, want %v", n, 1)
+	}
+	if n := len(got.Results.PartialMatchInstanceResults); n != 1 {
+		t.Errorf("simulateFiles() partially covered %v instances, want %v", n, 1)
+	}
+	if got := simulateFiles("testdata/no-such-file.json", ""); got.Err == nil {
+		t.Errorf("simulateFiles() error = nil, want error")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// parseStates parses comma-separated instance states, e.g. "running,stopped".
+func parseStates(s string) ([]types.InstanceStateName, error) {
+	var states []types.InstanceStateName
+	for _, v := range parseList(s) {
+		state := types.InstanceStateName(v)
+		if !containsState(state.Values(), state) {
+			return nil, fmt.Errorf("invalid -state: %s", v)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func containsState(states []types.InstanceStateName, state types.InstanceStateName) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Listed returns the results with the instances listed in reports.
+// Statistics, what-if summaries and exchanges are computed from Results,
+// as -state filters the listed rows only.
+func (r RegionResult) Listed() simurator.SimulatorResult {
+	return filterStates(r.Results, r.States)
+}
+
+// filterStates keeps only instances in one of the states in the report,
+// and the allocations to them.
+// RIs are still applied to every running instance before filtering.
+func filterStates(results simurator.SimulatorResult, states []types.InstanceStateName) simurator.SimulatorResult {
+	if len(states) == 0 {
+		return results
+	}
+	match := func(i types.Instance) bool {
+		return i.State != nil && containsState(states, i.State.Name)
+	}
+	filter := func(instances []types.Instance) []types.Instance {
+		var filtered []types.Instance
+		for _, i := range instances {
+			if match(i) {
+				filtered = append(filtered, i)
+			}
+		}
+		return filtered
+	}
+	results.MatchInstanceResults = filter(results.MatchInstanceResults)
+	results.UnmatchInstanceResults = filter(results.UnmatchInstanceResults)
+	var partial []simurator.PartialMatchInstanceResult
+	for _, i := range results.PartialMatchInstanceResults {
+		if match(i.Instance) {
+			partial = append(partial, i)
+		}
+	}
+	results.PartialMatchInstanceResults = partial
+
+	// only covered instances have allocations
+	listed := map[string]bool{}
+	for _, i := range results.MatchInstanceResults {
+		listed[aws.ToString(i.InstanceId)] = true
+	}
+	for _, i := range results.PartialMatchInstanceResults {
+		listed[aws.ToString(i.InstanceId)] = true
+	}
+	var allocations []simurator.Allocation
+	for _, a := range results.Allocations {
+		if listed[a.InstanceId] {
+			allocations = append(allocations, a)
+		}
+	}
+	results.Allocations = allocations
+	return results
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+func Test_parseStates(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []types.InstanceStateName
+		wantErr bool
+	}{
+		{name: "empty", s: "", want: nil},
+		{name: "running,stopped", s: "running,stopped", want: []types.InstanceStateName{"running", "stopped"}},
+		{name: "invalid", s: "running,sleeping", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStates(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseStates() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_filterStates(t *testing.T) {
+	instance := func(id string, state types.InstanceStateName) types.Instance {
+		return types.Instance{InstanceId: aws.String(id), State: &types.InstanceState{Name: state}}
+	}
+	results := simurator.SimulatorResult{
+		MatchInstanceResults: []types.Instance{instance("i-1", types.InstanceStateNameRunning)},
+		PartialMatchInstanceResults: []simurator.PartialMatchInstanceResult{
+			{Instance: instance("i-2", types.InstanceStateNameRunning), Units: 16, CoveredUnits: 8},
+		},
+		UnmatchInstanceResults: []types.Instance{
+			instance("i-3", types.InstanceStateNameRunning),
+			instance("i-4", types.InstanceStateNameStopped),
+		},
+		Allocations: []simurator.Allocation{
+			{ReservedInstancesId: "ri-1", InstanceId: "i-1", Units: 4},
+			{ReservedInstancesId: "ri-1", InstanceId: "i-2", Units: 8},
+		},
+	}
+	ids := func(r simurator.SimulatorResult) []string {
+		var ids []string
+		for _, i := range r.MatchInstanceResults {
+			ids = append(ids, *i.InstanceId)
+		}
+		for _, i := range r.PartialMatchInstanceResults {
+			ids = append(ids, *i.InstanceId)
+		}
+		for _, i := range r.UnmatchInstanceResults {
+			ids = append(ids, *i.InstanceId)
+		}
+		return ids
+	}
+	allocated := func(r simurator.SimulatorResult) []string {
+		var ids []string
+		for _, a := range r.Allocations {
+			ids = append(ids, a.InstanceId)
+		}
+		return ids
+	}
+	tests := []struct {
+		name          string
+		states        []types.InstanceStateName
+		want          []string
+		wantAllocated []string
+	}{
+		{name: "all", states: nil, want: []string{"i-1", "i-2", "i-3", "i-4"}, wantAllocated: []string{"i-1", "i-2"}},
+		{name: "running", states: []types.InstanceStateName{"running"}, want: []string{"i-1", "i-2", "i-3"}, wantAllocated: []string{"i-1", "i-2"}},
+		{name: "stopped", states: []types.InstanceStateName{"stopped"}, want: []string{"i-4"}, wantAllocated: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterStates(results, tt.states)
+			if ids := ids(got); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("filterStates() = %v, want %v", ids, tt.want)
+			}
+			if ids := allocated(got); !reflect.DeepEqual(ids, tt.wantAllocated) {
+				t.Errorf("filterStates() allocations = %v, want %v", ids, tt.wantAllocated)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// period is a number of months, weeks or days, e.g. of -interval and -within
+type period struct {
+	n    int
+	unit byte
+}
+
+var periodPattern = regexp.MustCompile(`^([1-9][0-9]*)([dwm])$`)
+
+// parsePeriod parses "3m" (months), "2w" (weeks) or "90d" (days),
+// the value of the flag name
+func parsePeriod(name, s string) (period, error) {
+	m := periodPattern.FindStringSubmatch(s)
+	if m == nil {
+		return period{}, fmt.Errorf("invalid -%s: %s (want e.g. 1m, 2w or 10d)", name, s)
+	}
+	n, _ := strconv.Atoi(m[1])
+	return period{n: n, unit: m[2][0]}, nil
+}
+
+// after returns the time k periods after start
+func (p period) after(start time.Time, k int) time.Time {
+	switch p.unit {
+	case 'm':
+		return start.AddDate(0, k*p.n, 0)
+	case 'w':
+		return start.AddDate(0, 0, 7*k*p.n)
+	default:
+		return start.AddDate(0, 0, k*p.n)
+	}
+}
+
+func (p period) String() string {
+	return fmt.Sprintf("%d%c", p.n, p.unit)
+}
+
+// parseDate parses "YYYY-MM-DD", the value of the flag name.
+// An empty value is the day of now.
+func parseDate(name, s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return now.UTC().Truncate(24 * time.Hour), nil
+	}
+	date, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -%s: %s (want YYYY-MM-DD)", name, s)
+	}
+	return date, nil
+}
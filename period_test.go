@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_parsePeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    period
+		wantErr bool
+	}{
+		{name: "months", s: "3m", want: period{n: 3, unit: 'm'}},
+		{name: "weeks", s: "2w", want: period{n: 2, unit: 'w'}},
+		{name: "days", s: "10d", want: period{n: 10, unit: 'd'}},
+		{name: "zero", s: "0m", wantErr: true},
+		{name: "years", s: "1y", wantErr: true},
+		{name: "no unit", s: "30", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePeriod("interval", tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parsePeriod() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parsePeriod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_period_after(t *testing.T) {
+	start := time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		p    period
+		k    int
+		want time.Time
+	}{
+		{name: "months", p: period{n: 3, unit: 'm'}, k: 2, want: time.Date(2022, 7, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "weeks", p: period{n: 2, unit: 'w'}, k: 1, want: time.Date(2022, 2, 14, 0, 0, 0, 0, time.UTC)},
+		{name: "days", p: period{n: 90, unit: 'd'}, k: 1, want: time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.after(start, tt.k); !got.Equal(tt.want) {
+				t.Errorf("period.after() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDate(t *testing.T) {
+	now := time.Date(2022, 7, 1, 15, 4, 5, 0, time.FixedZone("JST", 9*60*60))
+	tests := []struct {
+		name    string
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "date", s: "2023-04-01", want: time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "today", s: "", want: time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "invalid", s: "2023/04/01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDate("start", tt.s, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseDate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
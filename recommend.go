@@ -0,0 +1,200 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// RecommendedRI is a recommended reservation with the instances it is for
+type RecommendedRI struct {
+	HypotheticalRI
+	Units          float64  `json:"units"`
+	UncoveredUnits float64  `json:"uncovered_units"`
+	Instances      []string `json:"instances"`
+}
+
+// RecommendRegionReport is the recommendation of a region, simulated as
+// what-if RIs to show the coverage after the purchase
+type RecommendRegionReport struct {
+	Region            string          `json:"region"`
+	Error             string          `json:"error,omitempty"`
+	ReservedInstances []RecommendedRI `json:"reserved_instances"`
+	WhatIf            *WhatIfDelta    `json:"what_if,omitempty"`
+}
+
+// RecommendSchemaVersion is incremented on incompatible changes of RecommendReport
+const RecommendSchemaVersion = 1
+
+// RecommendReport is the document of "recommend -output json"
+type RecommendReport struct {
+	SchemaVersion  int                     `json:"schema_version"`
+	TargetCoverage float64                 `json:"target_coverage"`
+	Regions        []RecommendRegionReport `json:"regions"`
+}
+
+func toRecommendedRI(rec simurator.Recommendation, region string) RecommendedRI {
+	ri := RecommendedRI{
+		HypotheticalRI: HypotheticalRI{
+			InstanceCount:      rec.InstanceCount,
+			InstanceType:       string(rec.InstanceType),
+			ProductDescription: rec.ProductDescription,
+			Tenancy:            string(rec.Tenancy),
+		},
+		Units:          rec.Units,
+		UncoveredUnits: rec.UncoveredUnits,
+		Instances:      rec.Instances,
+	}
+	if rec.AvailabilityZone != "" {
+		ri.AvailabilityZone = rec.AvailabilityZone
+	} else {
+		ri.Region = region
+	}
+	return ri
+}
+
+// recommendRegion recommends RIs of a region and simulates them as what-if RIs
+func recommendRegion(r RegionResult, opts simurator.RecommendOptions) RecommendRegionReport {
+	report := RecommendRegionReport{Region: r.Region, ReservedInstances: []RecommendedRI{}}
+	if r.Err != nil {
+		report.Error = r.Err.Error()
+		return report
+	}
+	var ris []HypotheticalRI
+	for _, rec := range r.Results.Recommend(opts) {
+		ri := toRecommendedRI(rec, r.Region)
+		report.ReservedInstances = append(report.ReservedInstances, ri)
+		ris = append(ris, ri.HypotheticalRI)
+	}
+	if len(ris) > 0 {
+		report.WhatIf = whatIfDelta(simulateWhatIf(r, ris))
+	}
+	return report
+}
+
+func writeRecommendText(w io.Writer, report RecommendReport) {
+	for n, region := range report.Regions {
+		if region.Error != "" {
+			// the error itself is reported on errStream
+			continue
+		}
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "=== Recommended RIs: %s (target coverage %s) ===\n",
+			regionName(region.Region), formatPercent(report.TargetCoverage))
+		if len(region.ReservedInstances) == 0 {
+			fmt.Fprintln(w, "None")
+		} else {
+			fmt.Fprintf(w, "%5s  %-12s %-10s %-16s %-9s %8s  %s\n",
+				"Count", "Type", "Product", "Scope", "Tenancy", "Units", "Instances")
+		}
+		for _, ri := range region.ReservedInstances {
+			scope := ri.AvailabilityZone
+			if scope == "" {
+				scope = "Region"
+			}
+			fmt.Fprintf(w, "%5d  %-12s %-10s %-16s %-9s %8s  %s\n",
+				ri.InstanceCount,
+				ri.InstanceType,
+				ri.ProductDescription,
+				scope,
+				ri.Tenancy,
+				formatUnits(ri.Units),
+				strings.Join(ri.Instances, ", "))
+		}
+		if region.WhatIf != nil {
+			fmt.Fprintln(w)
+			printWhatIf(w, *region.WhatIf)
+		}
+	}
+}
+
+// writeRecommendScenario writes the recommended RIs of all regions as
+// a file of -scenario
+func writeRecommendScenario(path string, report RecommendReport) error {
+	scenario := Scenario{ReservedInstances: []HypotheticalRI{}}
+	for _, region := range report.Regions {
+		for _, ri := range region.ReservedInstances {
+			scenario.ReservedInstances = append(scenario.ReservedInstances, ri.HypotheticalRI)
+		}
+	}
+	return writeFile(path, func(w io.Writer) error {
+		return writeJSON(w, scenario)
+	})
+}
+
+// runRecommend is the "recommend" subcommand
+func (cli *CLI) runRecommend(args []string) int {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(cli.errStream)
+	var input inputFlags
+	input.register(flags)
+	target := flags.Float64("target", 100, "target RI coverage in `percent` of running instance units")
+	minAge := flags.Int("min-age", 0, "exclude instances launched less than `days` ago")
+	zonal := flags.Bool("zonal", false, "recommend zonal RIs in the Availability Zone of the instances")
+	output := flags.String("output", "text", "output `format`: text or json")
+	scenarioFile := flags.String("o", "", "also write the recommended RIs to a `file` for -scenario")
+	if code, ok := parseFlags(flags, args[1:]); !ok {
+		return code
+	}
+
+	if *target <= 0 || *target > 100 {
+		fmt.Fprintf(cli.errStream, "invalid -target: %g (want 0 < target <= 100)\n", *target)
+		return ExitCodeError
+	}
+	if *minAge < 0 {
+		fmt.Fprintf(cli.errStream, "invalid -min-age: %d\n", *minAge)
+		return ExitCodeError
+	}
+	if err := validateOutput(*output); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	if err := input.validate(); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+
+	regionResults, err := input.simulate()
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	exitCode := cli.reportErrors(regionResults)
+
+	opts := simurator.RecommendOptions{
+		TargetCoverage: *target,
+		MinAge:         time.Duration(*minAge) * 24 * time.Hour,
+		Now:            time.Now(),
+		Zonal:          *zonal,
+	}
+	report := RecommendReport{
+		SchemaVersion:  RecommendSchemaVersion,
+		TargetCoverage: *target,
+		Regions:        []RecommendRegionReport{},
+	}
+	for _, r := range regionResults {
+		report.Regions = append(report.Regions, recommendRegion(r, opts))
+	}
+
+	if *scenarioFile != "" {
+		if err := writeRecommendScenario(*scenarioFile, report); err != nil {
+			fmt.Fprintln(cli.errStream, err.Error())
+			return ExitCodeError
+		}
+	}
+	if *output == "json" {
+		if err := writeJSON(cli.outStream, report); err != nil {
+			fmt.Fprintln(cli.errStream, err.Error())
+			return ExitCodeError
+		}
+	} else {
+		writeRecommendText(cli.outStream, report)
+	}
+	return exitCode
+}
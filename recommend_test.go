@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+func Test_toRecommendedRI(t *testing.T) {
+	tests := []struct {
+		name   string
+		rec    simurator.Recommendation
+		region string
+		want   HypotheticalRI
+	}{
+		{
+			name:   "regional",
+			rec:    simurator.Recommendation{InstanceType: "m5.large", ProductDescription: "Linux/UNIX", Tenancy: "default", InstanceCount: 2},
+			region: "ap-northeast-1",
+			want:   HypotheticalRI{InstanceCount: 2, InstanceType: "m5.large", ProductDescription: "Linux/UNIX", Region: "ap-northeast-1", Tenancy: "default"},
+		},
+		{
+			name:   "zonal",
+			rec:    simurator.Recommendation{InstanceType: "t3.medium", ProductDescription: "Windows", Tenancy: "dedicated", AvailabilityZone: "ap-northeast-1a", InstanceCount: 1},
+			region: "ap-northeast-1",
+			want:   HypotheticalRI{InstanceCount: 1, InstanceType: "t3.medium", ProductDescription: "Windows", AvailabilityZone: "ap-northeast-1a", Tenancy: "dedicated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toRecommendedRI(tt.rec, tt.region).HypotheticalRI; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toRecommendedRI() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeRecommendScenario(t *testing.T) {
+	ris := []HypotheticalRI{
+		{InstanceCount: 2, InstanceType: "m5.large", ProductDescription: "Linux/UNIX", Region: "ap-northeast-1", Tenancy: "default"},
+		{InstanceCount: 1, InstanceType: "t3.medium", ProductDescription: "Windows", AvailabilityZone: "us-east-1a", Tenancy: "default"},
+	}
+	report := RecommendReport{
+		Regions: []RecommendRegionReport{
+			{Region: "ap-northeast-1", ReservedInstances: []RecommendedRI{{HypotheticalRI: ris[0], Units: 8}}},
+			{Region: "eu-west-1", Error: "UnauthorizedOperation"},
+			{Region: "us-east-1", ReservedInstances: []RecommendedRI{{HypotheticalRI: ris[1], Units: 2}}},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := writeRecommendScenario(path, report); err != nil {
+		t.Fatal(err)
+	}
+	// the recommendation can be simulated again with -scenario
+	got, err := loadScenario(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, ris) {
+		t.Errorf("loadScenario() = %+v, want %+v", got, ris)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// AllRegions is the -regions value to scan every enabled region
+const AllRegions = "all"
+
+// RegionResult is the simulation result of a region.
+// RIs are region-scoped, so each region is simulated independently.
+type RegionResult struct {
+	Region  string
+	Results simurator.SimulatorResult
+	// owner account of instances and RIs (multi-account mode only)
+	Accounts map[string]string
+	Err      error
+	// input of the simulation, for what-if simulations
+	Simulator *simurator.Simulator
+	// results without hypothetical RIs (what-if mode only)
+	Baseline *simurator.SimulatorResult
+	// instance states listed in reports (-state), all states if empty
+	States []types.InstanceStateName
+}
+
+// getRegions returns the names of regions enabled for the account
+func getRegions(client Ec2Client) ([]string, error) {
+	param := ec2.DescribeRegionsInput{
+		AllRegions: aws.Bool(false),
+	}
+	result, err := client.DescribeRegions(context.TODO(), &param)
+	if err != nil {
+		return nil, err
+	}
+	regions := make([]string, 0, len(result.Regions))
+	for _, r := range result.Regions {
+		regions = append(regions, aws.ToString(r.RegionName))
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// parseList splits "ap-northeast-1,us-east-1" into region names or account IDs
+func parseList(s string) []string {
+	list := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// simulateRegions fetches instances and RIs of each region concurrently
+// and simulates each region. Results are in the order of regions.
+// Instances and RIs of all accounts in a region are pooled; an empty
+// account means the default credentials.
+func simulateRegions(newClient func(region, account string) Ec2Client, regions, accounts []string) []RegionResult {
+	results := make([]RegionResult, len(regions))
+	var wg sync.WaitGroup
+	for n, region := range regions {
+		wg.Add(1)
+		go func(n int, region string) {
+			defer wg.Done()
+			results[n] = simulateRegion(newClient, region, accounts)
+		}(n, region)
+	}
+	wg.Wait()
+	return results
+}
+
+func simulateRegion(newClient func(region, account string) Ec2Client, region string, accounts []string) RegionResult {
+	result := RegionResult{Region: region}
+	sim := &simurator.Simulator{}
+	if len(accounts) > 1 {
+		result.Accounts = make(map[string]string)
+		sim.Accounts = result.Accounts
+	}
+
+	for _, account := range accounts {
+		client := newClient(region, account)
+		instances, err := getInstances(client)
+		if err != nil {
+			result.Err = accountError(account, err)
+			return result
+		}
+		ri_instances, err := getReservedInstances(client)
+		if err != nil {
+			result.Err = accountError(account, err)
+			return result
+		}
+
+		if sim.Accounts != nil {
+			for _, i := range instances {
+				sim.Accounts[aws.ToString(i.InstanceId)] = account
+			}
+			for _, ri := range ri_instances {
+				sim.Accounts[aws.ToString(ri.ReservedInstancesId)] = account
+			}
+		}
+		sim.Instances = append(sim.Instances, instances...)
+		sim.ReservedInstances = append(sim.ReservedInstances, ri_instances...)
+	}
+
+	result.Simulator = sim
+	result.Results, result.Err = sim.Simulate()
+	return result
+}
+
+func accountError(account string, err error) error {
+	if account == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", account, err)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func Test_getRegions(t *testing.T) {
+	client := MockEc2Client{
+		regions: []string{"us-east-1", "ap-northeast-1", "eu-west-1"},
+	}
+	want := []string{"ap-northeast-1", "eu-west-1", "us-east-1"}
+	got, err := getRegions(client)
+	if err != nil {
+		t.Errorf("getRegions() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getRegions() = %v, want %v", got, want)
+	}
+}
+
+func Test_parseList(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{s: "ap-northeast-1", want: []string{"ap-northeast-1"}},
+		{s: "ap-northeast-1, us-east-1,,eu-west-1", want: []string{"ap-northeast-1", "us-east-1", "eu-west-1"}},
+		{s: "", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseList(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_simulateRegions(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	clients := map[string]Ec2Client{
+		"ap-northeast-1": MockEc2Client{
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "t3.medium"},
+			},
+			reservedInstances: []types.ReservedInstances{
+				{
+					InstanceCount:      aws.Int32(1),
+					InstanceType:       "t3.medium",
+					ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				},
+			},
+		},
+		// RIs of another region never cover instances of this region
+		"us-east-1": MockEc2Client{
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "t3.medium"},
+			},
+		},
+		"eu-west-1": MockEc2Client{
+			instances: []types.Instance{
+				{InstanceType: "t1.dummy", Platform: "Plan9"},
+			},
+		},
+	}
+	newClient := func(region, account string) Ec2Client {
+		return clients[region]
+	}
+
+	got := simulateRegions(newClient, []string{"ap-northeast-1", "us-east-1", "eu-west-1"}, []string{""})
+	if len(got) != 3 {
+		t.Fatalf("simulateRegions() returns %v results, want %v", len(got), 3)
+	}
+	if got[0].Region != "ap-northeast-1" || len(got[0].Results.MatchInstanceResults) != 1 {
+		t.Errorf("simulateRegions()[0] = %v, want 1 covered instance in ap-northeast-1", got[0])
+	}
+	if got[1].Region != "us-east-1" || len(got[1].Results.UnmatchInstanceResults) != 1 {
+		t.Errorf("simulateRegions()[1] = %v, want 1 uncovered instance in us-east-1", got[1])
+	}
+	if got[2].Region != "eu-west-1" || got[2].Err == nil {
+		t.Errorf("simulateRegions()[2] = %v, want error in eu-west-1", got[2])
+	}
+}
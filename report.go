@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// Renderer writes the report of simulation results
+type Renderer interface {
+	Render(w io.Writer, regionResults []RegionResult) error
+}
+
+type RenderOptions struct {
+	// text, markdown, html: print which RI covers which instance
+	ShowAllocations bool
+	// csv/tsv: write each section to a file in the directory
+	OutputDir string
+}
+
+// NewRenderer returns the renderer of the -output format
+func NewRenderer(format string, opts RenderOptions) (Renderer, error) {
+	if opts.ShowAllocations && (format == "csv" || format == "tsv") {
+		// rows of csv/tsv are instances and RIs; allocations are in -output json
+		return nil, fmt.Errorf("-allocations cannot be used with -output %s", format)
+	}
+	switch format {
+	case "text":
+		return TextRenderer{ShowAllocations: opts.ShowAllocations}, nil
+	case "json":
+		return JSONRenderer{}, nil
+	case "csv":
+		return CSVRenderer{Comma: ',', Ext: ".csv", OutputDir: opts.OutputDir}, nil
+	case "tsv":
+		return CSVRenderer{Comma: '\t', Ext: ".tsv", OutputDir: opts.OutputDir}, nil
+	case "markdown":
+		return MarkdownRenderer{ShowAllocations: opts.ShowAllocations}, nil
+	case "html":
+		return HTMLRenderer{ShowAllocations: opts.ShowAllocations}, nil
+	}
+	return nil, fmt.Errorf("invalid -output: %s", format)
+}
+
+// StatisticsRow is a row of the coverage and utilization summary
+type StatisticsRow struct {
+	// "total", "region", "family" or "platform"
+	Group string
+	Key   string
+	simurator.Statistics
+}
+
+// summarizeStatistics returns the total statistics of all regions followed by
+// the statistics per region (if several), per instance family and per platform.
+// It returns nil when no region was simulated.
+func summarizeStatistics(regionResults []RegionResult) []StatisticsRow {
+	var total simurator.Statistics
+	var regions []StatisticsRow
+	families := map[string]simurator.Statistics{}
+	platforms := map[string]simurator.Statistics{}
+	for _, r := range regionResults {
+		if r.Err != nil {
+			continue
+		}
+		stats := r.Results.Statistics()
+		total = total.Add(stats)
+		regions = append(regions, StatisticsRow{Group: "region", Key: regionName(r.Region), Statistics: stats})
+		for k, s := range r.Results.StatisticsByFamily() {
+			families[k] = families[k].Add(s)
+		}
+		for k, s := range r.Results.StatisticsByPlatform() {
+			platforms[k] = platforms[k].Add(s)
+		}
+	}
+
+	if len(regions) == 0 {
+		return nil
+	}
+	rows := []StatisticsRow{{Group: "total", Key: "", Statistics: total}}
+	if len(regions) > 1 {
+		rows = append(rows, regions...)
+	}
+	rows = append(rows, statisticsRows("family", families)...)
+	rows = append(rows, statisticsRows("platform", platforms)...)
+	return rows
+}
+
+func statisticsRows(group string, stats map[string]simurator.Statistics) []StatisticsRow {
+	rows := make([]StatisticsRow, 0, len(stats))
+	for k, s := range stats {
+		rows = append(rows, StatisticsRow{Group: group, Key: k, Statistics: s})
+	}
+	OrderBy(func(r1, r2 StatisticsRow) bool { return r1.Key < r2.Key }).Sort(rows)
+	return rows
+}
+
+func formatPercent(f float64) string {
+	return fmt.Sprintf("%.1f%%", f)
+}
+
+// formatCoverage formats the coverage, or "-" without running instances
+func formatCoverage(s simurator.Statistics) string {
+	if s.RunningUnits == 0 {
+		return "-"
+	}
+	return formatPercent(s.Coverage())
+}
+
+// formatUtilization formats the utilization, or "-" without RIs
+func formatUtilization(s simurator.Statistics) string {
+	if s.PurchasedUnits == 0 {
+		return "-"
+	}
+	return formatPercent(s.Utilization())
+}
+
+func formatUnits(f float64) string {
+	return fmt.Sprintf("%.2f", f)
+}
+
+// regionName is the name of a region in reports; offline input has no region
+func regionName(region string) string {
+	if region == "" {
+		return "(default)"
+	}
+	return region
+}
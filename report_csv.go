@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// report sections of CSV/TSV
+const (
+	SectionCovered                 = "covered"
+	SectionPartiallyCovered        = "partially_covered"
+	SectionUncovered               = "uncovered"
+	SectionUnusedReservedInstances = "unused_reserved_instances"
+)
+
+var instanceHeader = []string{
+	"region", "account", "instance_id", "instance_type", "platform", "name", "state",
+	"availability_zone", "tenancy", "units", "covered_units",
+}
+
+var reservedInstanceHeader = []string{
+	"region", "account", "reserved_instances_id", "instance_type", "product_description", "scope", "tenancy",
+	"offering_type", "offering_class", "instance_count", "remaining_count", "units", "used_units", "remaining_units", "end",
+}
+
+// one file with a section column has the columns of both tables;
+// for RIs, platform is the product description, availability_zone is the scope
+// and covered_units is the used units.
+// account is the owner account of multi-account mode (-accounts).
+var sectionHeader = []string{
+	"section", "region", "account", "id", "instance_type", "platform", "name", "state",
+	"availability_zone", "tenancy", "offering_type", "offering_class", "instance_count", "units", "covered_units", "remaining_units", "end",
+}
+
+// CSVRenderer writes report sections as CSV or TSV
+type CSVRenderer struct {
+	Comma rune
+	// file extension of OutputDir files
+	Ext string
+	// write each section to a file in the directory instead of one table
+	OutputDir string
+}
+
+func (r CSVRenderer) Render(w io.Writer, regionResults []RegionResult) error {
+	if r.OutputDir != "" {
+		return writeSectionFiles(r.OutputDir, r.Ext, r.Comma, regionResults)
+	}
+	return writeSectionTable(w, r.Comma, regionResults)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func instanceRow(region string, i JSONInstance) []string {
+	return []string{
+		region, i.Account, i.InstanceId, i.InstanceType, i.Platform, i.Name, i.State,
+		i.AvailabilityZone, i.Tenancy, formatFloat(i.Units), formatFloat(i.CoveredUnits),
+	}
+}
+
+func reservedInstanceRow(region string, ri JSONReservedInstance) []string {
+	return []string{
+		region, ri.Account, ri.ReservedInstancesId, ri.InstanceType, ri.ProductDescription, ri.Scope, ri.Tenancy,
+		ri.OfferingType, ri.OfferingClass, strconv.Itoa(int(ri.InstanceCount)), formatFloat(ri.RemainingCount),
+		formatFloat(ri.Units), formatFloat(ri.UsedUnits), formatFloat(ri.RemainingUnits), formatTime(ri.End),
+	}
+}
+
+func instanceSectionRow(section, region string, i JSONInstance) []string {
+	return []string{
+		section, region, i.Account, i.InstanceId, i.InstanceType, i.Platform, i.Name, i.State,
+		i.AvailabilityZone, i.Tenancy, "", "", "", formatFloat(i.Units), formatFloat(i.CoveredUnits), "", "",
+	}
+}
+
+func reservedInstanceSectionRow(section, region string, ri JSONReservedInstance) []string {
+	return []string{
+		section, region, ri.Account, ri.ReservedInstancesId, ri.InstanceType, ri.ProductDescription, "", "",
+		ri.Scope, ri.Tenancy, ri.OfferingType, ri.OfferingClass, strconv.Itoa(int(ri.InstanceCount)), formatFloat(ri.Units),
+		formatFloat(ri.UsedUnits), formatFloat(ri.RemainingUnits), formatTime(ri.End),
+	}
+}
+
+func newTableWriter(w io.Writer, comma rune) *csv.Writer {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	return writer
+}
+
+// writeSectionTable writes every section as one table with a section column
+func writeSectionTable(w io.Writer, comma rune, regionResults []RegionResult) error {
+	writer := newTableWriter(w, comma)
+	writer.Write(sectionHeader)
+	for _, r := range regionResults {
+		report := toJSONRegionReport(r)
+		for _, i := range report.CoveredInstances {
+			writer.Write(instanceSectionRow(SectionCovered, report.Region, i))
+		}
+		for _, i := range report.PartiallyCoveredInstances {
+			writer.Write(instanceSectionRow(SectionPartiallyCovered, report.Region, i))
+		}
+		for _, i := range report.UncoveredInstances {
+			writer.Write(instanceSectionRow(SectionUncovered, report.Region, i))
+		}
+		for _, ri := range report.UnusedReservedInstances {
+			writer.Write(reservedInstanceSectionRow(SectionUnusedReservedInstances, report.Region, ri))
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeSectionFiles writes each section to its own file in dir,
+// e.g. covered.csv, uncovered.csv
+func writeSectionFiles(dir, ext string, comma rune, regionResults []RegionResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	reports := make([]JSONRegionReport, 0, len(regionResults))
+	for _, r := range regionResults {
+		reports = append(reports, toJSONRegionReport(r))
+	}
+
+	instanceSections := []struct {
+		name      string
+		instances func(JSONRegionReport) []JSONInstance
+	}{
+		{SectionCovered, func(r JSONRegionReport) []JSONInstance { return r.CoveredInstances }},
+		{SectionPartiallyCovered, func(r JSONRegionReport) []JSONInstance { return r.PartiallyCoveredInstances }},
+		{SectionUncovered, func(r JSONRegionReport) []JSONInstance { return r.UncoveredInstances }},
+	}
+	for _, section := range instanceSections {
+		err := writeFile(filepath.Join(dir, section.name+ext), func(w io.Writer) error {
+			writer := newTableWriter(w, comma)
+			writer.Write(instanceHeader)
+			for _, r := range reports {
+				for _, i := range section.instances(r) {
+					writer.Write(instanceRow(r.Region, i))
+				}
+			}
+			writer.Flush()
+			return writer.Error()
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFile(filepath.Join(dir, SectionUnusedReservedInstances+ext), func(w io.Writer) error {
+		writer := newTableWriter(w, comma)
+		writer.Write(reservedInstanceHeader)
+		for _, r := range reports {
+			for _, ri := range r.UnusedReservedInstances {
+				writer.Write(reservedInstanceRow(r.Region, ri))
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
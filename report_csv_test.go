@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testRegionResults() []RegionResult {
+	r := simulateFiles("testdata/describe-instances.json", "testdata/describe-reserved-instances.json")
+	r.Region = "ap-northeast-1"
+	return []RegionResult{r}
+}
+
+func Test_writeSectionTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSectionTable(&buf, ',', testRegionResults()); err != nil {
+		t.Fatalf("writeSectionTable() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("writeSectionTable() writes invalid CSV: %v", err)
+	}
+	if len(records) != 6 {
+		t.Fatalf("writeSectionTable() writes %v records, want %v", len(records), 6)
+	}
+	if !reflect.DeepEqual(records[0], sectionHeader) {
+		t.Errorf("header = %v, want %v", records[0], sectionHeader)
+	}
+	var sections []string
+	for _, r := range records[1:] {
+		sections = append(sections, r[0])
+	}
+	wantSections := []string{
+		SectionCovered,
+		SectionPartiallyCovered,
+		SectionUncovered,
+		SectionUncovered,
+		SectionUnusedReservedInstances,
+	}
+	if !reflect.DeepEqual(sections, wantSections) {
+		t.Errorf("sections = %v, want %v", sections, wantSections)
+	}
+	// Name tags with commas and quotes survive the round trip
+	if got := records[2][6]; got != "batch, nightly" {
+		t.Errorf("name = %v, want %v", got, "batch, nightly")
+	}
+	if got := records[4][6]; got != `"legacy" app` {
+		t.Errorf("name = %v, want %v", got, `"legacy" app`)
+	}
+}
+
+func Test_writeSectionTable_accounts(t *testing.T) {
+	r := testRegionResults()[0]
+	r.Accounts = map[string]string{
+		"i-000000000001":                       "111111111111",
+		"11111111-aaaa-bbbb-cccc-000000000002": "222222222222",
+	}
+	var buf bytes.Buffer
+	if err := writeSectionTable(&buf, ',', []RegionResult{r}); err != nil {
+		t.Fatalf("writeSectionTable() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("writeSectionTable() writes invalid CSV: %v", err)
+	}
+	accounts := map[string]string{}
+	for _, r := range records[1:] {
+		accounts[r[3]] = r[2]
+	}
+	want := map[string]string{
+		"i-000000000001":                       "111111111111",
+		"i-000000000002":                       "",
+		"i-000000000003":                       "",
+		"i-000000000004":                       "",
+		"11111111-aaaa-bbbb-cccc-000000000002": "222222222222",
+	}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("accounts = %v, want %v", accounts, want)
+	}
+}
+
+func Test_writeSectionFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "report")
+	if err := writeSectionFiles(dir, ".tsv", '\t', testRegionResults()); err != nil {
+		t.Fatalf("writeSectionFiles() error = %v", err)
+	}
+	tests := []struct {
+		file   string
+		header []string
+		rows   int
+	}{
+		{file: "covered.tsv", header: instanceHeader, rows: 1},
+		{file: "partially_covered.tsv", header: instanceHeader, rows: 1},
+		{file: "uncovered.tsv", header: instanceHeader, rows: 2},
+		{file: "unused_reserved_instances.tsv", header: reservedInstanceHeader, rows: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			reader := csv.NewReader(f)
+			reader.Comma = '\t'
+			records, err := reader.ReadAll()
+			if err != nil {
+				t.Fatalf("invalid TSV: %v", err)
+			}
+			if !reflect.DeepEqual(records[0], tt.header) {
+				t.Errorf("header = %v, want %v", records[0], tt.header)
+			}
+			if len(records)-1 != tt.rows {
+				t.Errorf("rows = %v, want %v", len(records)-1, tt.rows)
+			}
+			for _, r := range records[1:] {
+				if r[0] != "ap-northeast-1" {
+					t.Errorf("region = %v, want %v", r[0], "ap-northeast-1")
+				}
+			}
+		})
+	}
+}
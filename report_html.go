@@ -0,0 +1,179 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// HTMLRenderer writes the report as a self-contained HTML page
+type HTMLRenderer struct {
+	ShowAllocations bool
+}
+
+type htmlRegion struct {
+	Name       string
+	Error      string
+	Statistics simurator.Statistics
+	Report     JSONRegionReport
+	WhatIf     []whatIfRow
+	// -allocations
+	ShowAllocations bool
+}
+
+type htmlReport struct {
+	Statistics []StatisticsRow
+	Regions    []htmlRegion
+}
+
+type htmlSection struct {
+	Title     string
+	Instances []JSONInstance
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"coverage":    formatCoverage,
+	"utilization": formatUtilization,
+	"units":       func(f float64) string { return formatUnits(f) },
+	"time":        formatTime,
+	"join":        func(s []string) string { return strings.Join(s, ", ") },
+	"section": func(title string, instances []JSONInstance) htmlSection {
+		return htmlSection{Title: title, Instances: instances}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>RI simulation report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.6em; text-align: left; }
+th { background: #f0f0f0; }
+td.num { text-align: right; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>RI simulation report</h1>
+{{- if .Statistics}}
+<table>
+<tr><th>Group</th><th>Key</th><th>Running units</th><th>Covered units</th><th>Coverage</th><th>Purchased units</th><th>Used units</th><th>Utilization</th></tr>
+{{- range .Statistics}}
+<tr><td>{{.Group}}</td><td>{{.Key}}</td><td class="num">{{units .RunningUnits}}</td><td class="num">{{units .CoveredUnits}}</td><td class="num">{{coverage .Statistics}}</td><td class="num">{{units .PurchasedUnits}}</td><td class="num">{{units .UsedUnits}}</td><td class="num">{{utilization .Statistics}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<table>
+<tr><th>Region</th><th>Coverage</th><th>Utilization</th><th>Covered</th><th>Partially covered</th><th>Not covered</th><th>Unused RIs</th></tr>
+{{- range .Regions}}
+{{- if .Error}}
+<tr><td>{{.Name}}</td><td class="error" colspan="6">error</td></tr>
+{{- else}}
+<tr><td>{{.Name}}</td><td class="num">{{coverage .Statistics}}</td><td class="num">{{utilization .Statistics}}</td><td class="num">{{.Report.Summary.Covered}}</td><td class="num">{{.Report.Summary.PartiallyCovered}}</td><td class="num">{{.Report.Summary.Uncovered}}</td><td class="num">{{.Report.Summary.UnusedReservedInstances}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- range .Regions}}
+<h2>{{.Name}}</h2>
+{{- if .Error}}
+<p class="error">Error: {{.Error}}</p>
+{{- else}}
+<p>Coverage: <strong>{{coverage .Statistics}}</strong> ({{units .Statistics.CoveredUnits}} / {{units .Statistics.RunningUnits}} units)<br>
+Utilization: <strong>{{utilization .Statistics}}</strong> ({{units .Statistics.UsedUnits}} / {{units .Statistics.PurchasedUnits}} units)</p>
+{{template "instances" section "RI covered instances" .Report.CoveredInstances}}
+{{template "instances" section "RI partially covered instances" .Report.PartiallyCoveredInstances}}
+{{template "instances" section "RI *NOT* covered instances" .Report.UncoveredInstances}}
+<h3>Purchased but not applied RI</h3>
+{{- if .Report.UnusedReservedInstances}}
+<table>
+<tr><th>RI ID</th><th>Type</th><th>Product</th><th>Scope</th><th>Tenancy</th><th>Class</th><th>Offering</th><th>Remaining count</th><th>Remaining units</th><th>End</th></tr>
+{{- range .Report.UnusedReservedInstances}}
+<tr><td>{{.ReservedInstancesId}}</td><td>{{.InstanceType}}</td><td>{{.ProductDescription}}</td><td>{{.Scope}}</td><td>{{.Tenancy}}</td><td>{{.OfferingClass}}</td><td>{{.OfferingType}}</td><td class="num">{{units .RemainingCount}}/{{.InstanceCount}}</td><td class="num">{{units .RemainingUnits}}/{{units .Units}}</td><td>{{time .End}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>None</p>
+{{- end}}
+<h3>Convertible RI exchanges</h3>
+{{- if .Report.ConvertibleExchanges}}
+<table>
+<tr><th>RI ID</th><th>Units</th><th>Count</th><th>Type</th><th>Product</th><th>Tenancy</th><th>Instances</th></tr>
+{{- range .Report.ConvertibleExchanges}}
+<tr><td>{{.ReservedInstancesId}}</td><td class="num">{{units .Units}}</td><td class="num">{{.Target.InstanceCount}}</td><td>{{.Target.InstanceType}}</td><td>{{.Target.ProductDescription}}</td><td>{{.Target.Tenancy}}</td><td>{{join .Target.Instances}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>None</p>
+{{- end}}
+{{- if .ShowAllocations}}
+<h3>RI allocations</h3>
+{{- if .Report.Allocations}}
+<table>
+<tr><th>RI ID</th><th>Instance ID</th><th>Units</th></tr>
+{{- range .Report.Allocations}}
+<tr><td>{{.ReservedInstancesId}}</td><td>{{.InstanceId}}</td><td class="num">{{units .Units}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>None</p>
+{{- end}}
+{{- end}}
+{{- if .Report.Accounts}}
+<h3>Coverage per account</h3>
+<table>
+<tr><th>Account</th><th>Covered</th><th>Partially covered</th><th>Not covered</th></tr>
+{{- range .Report.Accounts}}
+<tr><td>{{.Account}}</td><td class="num">{{.Covered}}</td><td class="num">{{.Partial}}</td><td class="num">{{.Not}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .WhatIf}}
+<h3>What-if compared with current RIs</h3>
+<table>
+<tr><th></th><th>Current</th><th>What-if</th><th>Delta</th></tr>
+{{- range .WhatIf}}
+<tr><td>{{.Label}}</td><td class="num">{{.Current}}</td><td class="num">{{.WhatIf}}</td><td class="num">{{.Delta}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+{{define "instances"}}<h3>{{.Title}}</h3>
+{{- if .Instances}}
+<table>
+<tr><th>Instance ID</th><th>Type</th><th>Platform</th><th>Name</th><th>State</th><th>AZ</th><th>Tenancy</th><th>Covered units</th></tr>
+{{- range .Instances}}
+<tr><td>{{.InstanceId}}</td><td>{{.InstanceType}}</td><td>{{.Platform}}</td><td>{{.Name}}</td><td>{{.State}}</td><td>{{.AvailabilityZone}}</td><td>{{.Tenancy}}</td><td class="num">{{units .CoveredUnits}}/{{units .Units}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>None</p>
+{{- end}}
+{{- end}}
+`))
+
+func (r HTMLRenderer) Render(w io.Writer, regionResults []RegionResult) error {
+	regions := make([]htmlRegion, 0, len(regionResults))
+	for _, result := range regionResults {
+		region := htmlRegion{Name: regionName(result.Region), ShowAllocations: r.ShowAllocations}
+		if result.Err != nil {
+			region.Error = result.Err.Error()
+		} else {
+			region.Statistics = result.Results.Statistics()
+			region.Report = toJSONRegionReport(result)
+			if region.Report.WhatIf != nil {
+				region.WhatIf = whatIfRows(*region.Report.WhatIf)
+			}
+		}
+		regions = append(regions, region)
+	}
+	return htmlTemplate.Execute(w, htmlReport{
+		Statistics: summarizeStatistics(regionResults),
+		Regions:    regions,
+	})
+}
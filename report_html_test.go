@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTMLRenderer_Render(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (HTMLRenderer{}).Render(&buf, testRegionResults()); err != nil {
+		t.Fatalf("HTMLRenderer.Render() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		"<h2>ap-northeast-1</h2>",
+		"<strong>72.7%</strong>",
+		"<td>&#34;legacy&#34; app</td>",
+		"<td>11111111-aaaa-bbbb-cccc-000000000002</td>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTMLRenderer.Render() does not contain %q\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "RI allocations") {
+		t.Errorf("HTMLRenderer.Render() prints allocations without ShowAllocations")
+	}
+	// self-contained: no external stylesheets or scripts
+	for _, unwanted := range []string{"<link", "<script"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("HTMLRenderer.Render() contains %q", unwanted)
+		}
+	}
+}
+
+func TestHTMLRenderer_Render_accounts(t *testing.T) {
+	r := testRegionResults()[0]
+	r.Accounts = map[string]string{
+		"i-000000000001": "111111111111",
+		"i-000000000002": "111111111111",
+		"i-000000000003": "222222222222",
+		"i-000000000004": "222222222222",
+	}
+	var buf bytes.Buffer
+	if err := (HTMLRenderer{ShowAllocations: true}).Render(&buf, []RegionResult{r}); err != nil {
+		t.Fatalf("HTMLRenderer.Render() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"<h3>RI allocations</h3>",
+		`<tr><td>11111111-aaaa-bbbb-cccc-000000000001</td><td>i-000000000002</td><td class="num">12.00</td></tr>`,
+		"<h3>Coverage per account</h3>",
+		`<tr><td>111111111111</td><td class="num">1</td><td class="num">1</td><td class="num">0</td></tr>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("HTMLRenderer.Render() does not contain %q\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// JSONSchemaVersion is incremented on incompatible changes of JSONReport
+const JSONSchemaVersion = 1
+
+// JSONReport is the document of "-output json"
+type JSONReport struct {
+	SchemaVersion int `json:"schema_version"`
+	// total of all regions
+	Summary JSONSummary `json:"summary"`
+	// coverage and utilization in total, per region, family and platform
+	Statistics []JSONStatistics   `json:"statistics"`
+	Regions    []JSONRegionReport `json:"regions"`
+}
+
+// JSONStatistics is a row of the coverage and utilization summary.
+// Coverage and Utilization are percentages, null without running
+// instances or RIs respectively.
+type JSONStatistics struct {
+	Group          string   `json:"group"`
+	Key            string   `json:"key"`
+	RunningUnits   float64  `json:"running_units"`
+	CoveredUnits   float64  `json:"covered_units"`
+	Coverage       *float64 `json:"coverage"`
+	PurchasedUnits float64  `json:"purchased_units"`
+	UsedUnits      float64  `json:"used_units"`
+	Utilization    *float64 `json:"utilization"`
+}
+
+type JSONRegionReport struct {
+	Region                    string                 `json:"region"`
+	Error                     string                 `json:"error,omitempty"`
+	Summary                   JSONSummary            `json:"summary"`
+	CoveredInstances          []JSONInstance         `json:"covered_instances"`
+	PartiallyCoveredInstances []JSONInstance         `json:"partially_covered_instances"`
+	UncoveredInstances        []JSONInstance         `json:"uncovered_instances"`
+	UnusedReservedInstances   []JSONReservedInstance `json:"unused_reserved_instances"`
+	Allocations               []JSONAllocation       `json:"allocations"`
+	Accounts                  []AccountSummary       `json:"accounts,omitempty"`
+	// unused units of convertible RIs which could be exchanged
+	// to cover uncovered instances
+	ConvertibleExchanges []JSONExchange `json:"convertible_exchanges"`
+	// current RIs compared with the what-if RIs (-add-ri, -scenario)
+	WhatIf *WhatIfDelta `json:"what_if,omitempty"`
+}
+
+type JSONSummary struct {
+	Covered                 int `json:"covered"`
+	PartiallyCovered        int `json:"partially_covered"`
+	Uncovered               int `json:"uncovered"`
+	UnusedReservedInstances int `json:"unused_reserved_instances"`
+}
+
+type JSONInstance struct {
+	InstanceId       string  `json:"instance_id"`
+	InstanceType     string  `json:"instance_type"`
+	Platform         string  `json:"platform"`
+	Name             string  `json:"name"`
+	State            string  `json:"state"`
+	AvailabilityZone string  `json:"availability_zone"`
+	Tenancy          string  `json:"tenancy"`
+	Units            float64 `json:"units"`
+	CoveredUnits     float64 `json:"covered_units"`
+	// owner account (multi-account mode only)
+	Account string `json:"account,omitempty"`
+}
+
+type JSONReservedInstance struct {
+	ReservedInstancesId string     `json:"reserved_instances_id"`
+	InstanceType        string     `json:"instance_type"`
+	ProductDescription  string     `json:"product_description"`
+	Scope               string     `json:"scope"`
+	Tenancy             string     `json:"tenancy"`
+	OfferingType        string     `json:"offering_type"`
+	OfferingClass       string     `json:"offering_class"`
+	InstanceCount       int32      `json:"instance_count"`
+	RemainingCount      float64    `json:"remaining_count"`
+	Units               float64    `json:"units"`
+	UsedUnits           float64    `json:"used_units"`
+	RemainingUnits      float64    `json:"remaining_units"`
+	End                 *time.Time `json:"end"`
+	// owner account (multi-account mode only)
+	Account string `json:"account,omitempty"`
+}
+
+// JSONExchange proposes to exchange units of a convertible RI for Target
+type JSONExchange struct {
+	ReservedInstancesId string        `json:"reserved_instances_id"`
+	Units               float64       `json:"units"`
+	Target              RecommendedRI `json:"target"`
+}
+
+type JSONAllocation struct {
+	ReservedInstancesId string  `json:"reserved_instances_id"`
+	InstanceId          string  `json:"instance_id"`
+	Units               float64 `json:"units"`
+}
+
+func toJSONInstance(i types.Instance, units, covered float64) JSONInstance {
+	ji := JSONInstance{
+		InstanceId:   aws.ToString(i.InstanceId),
+		InstanceType: string(i.InstanceType),
+		Platform:     string(i.Platform),
+		Name:         ToName(i.Tags),
+		State:        string(ToState(i)),
+		Tenancy:      string(simurator.InstanceTenancy(i)),
+		Units:        units,
+		CoveredUnits: covered,
+	}
+	if i.Placement != nil {
+		ji.AvailabilityZone = aws.ToString(i.Placement.AvailabilityZone)
+	}
+	return ji
+}
+
+func toJSONReservedInstance(ri simurator.ReservedInstanceResult) JSONReservedInstance {
+	return JSONReservedInstance{
+		ReservedInstancesId: aws.ToString(ri.ReservedInstancesId),
+		InstanceType:        string(ri.InstanceType),
+		ProductDescription:  string(ri.ProductDescription),
+		Scope:               ToScope(ri.ReservedInstances),
+		Tenancy:             string(simurator.ReservedInstanceTenancy(ri.ReservedInstances)),
+		OfferingType:        string(ri.OfferingType),
+		OfferingClass:       string(ri.OfferingClass),
+		InstanceCount:       aws.ToInt32(ri.InstanceCount),
+		RemainingCount:      ri.RemainingCount(),
+		Units:               ri.Units,
+		UsedUnits:           ri.UsedUnits,
+		RemainingUnits:      ri.RemainingUnits,
+		End:                 ri.End,
+	}
+}
+
+func toJSONRegionReport(r RegionResult) JSONRegionReport {
+	report := JSONRegionReport{
+		Region:                    r.Region,
+		CoveredInstances:          []JSONInstance{},
+		PartiallyCoveredInstances: []JSONInstance{},
+		UncoveredInstances:        []JSONInstance{},
+		UnusedReservedInstances:   []JSONReservedInstance{},
+		Allocations:               []JSONAllocation{},
+		ConvertibleExchanges:      []JSONExchange{},
+	}
+	if r.Err != nil {
+		report.Error = r.Err.Error()
+		return report
+	}
+
+	// r.Accounts is nil in single-account mode
+	instance := func(i types.Instance, units, covered float64) JSONInstance {
+		ji := toJSONInstance(i, units, covered)
+		ji.Account = r.Accounts[ji.InstanceId]
+		return ji
+	}
+	results := r.Listed()
+	for _, i := range results.MatchInstanceResults {
+		units := simurator.Units(i.InstanceType)
+		report.CoveredInstances = append(report.CoveredInstances, instance(i, units, units))
+	}
+	for _, i := range results.PartialMatchInstanceResults {
+		report.PartiallyCoveredInstances = append(report.PartiallyCoveredInstances, instance(i.Instance, i.Units, i.CoveredUnits))
+	}
+	for _, i := range results.UnmatchInstanceResults {
+		units := simurator.Units(i.InstanceType)
+		report.UncoveredInstances = append(report.UncoveredInstances, instance(i, units, 0))
+	}
+	for _, ri := range results.UnmatchReservedInstanceResults {
+		jri := toJSONReservedInstance(ri)
+		jri.Account = r.Accounts[jri.ReservedInstancesId]
+		report.UnusedReservedInstances = append(report.UnusedReservedInstances, jri)
+	}
+	for _, a := range results.Allocations {
+		report.Allocations = append(report.Allocations, JSONAllocation(a))
+	}
+	if r.Accounts != nil {
+		report.Accounts = summarizeAccounts(r.Results, r.Accounts)
+	}
+	for _, e := range r.Results.ConvertibleExchanges() {
+		report.ConvertibleExchanges = append(report.ConvertibleExchanges, JSONExchange{
+			ReservedInstancesId: e.ReservedInstancesId,
+			Units:               e.Units,
+			Target:              toRecommendedRI(e.Target, r.Region),
+		})
+	}
+
+	report.Summary = JSONSummary{
+		Covered:                 len(report.CoveredInstances),
+		PartiallyCovered:        len(report.PartiallyCoveredInstances),
+		Uncovered:               len(report.UncoveredInstances),
+		UnusedReservedInstances: len(report.UnusedReservedInstances),
+	}
+	report.WhatIf = whatIfDelta(r)
+	return report
+}
+
+// JSONRenderer writes JSONReport
+type JSONRenderer struct{}
+
+func (r JSONRenderer) Render(w io.Writer, regionResults []RegionResult) error {
+	return writeJSONReport(w, regionResults)
+}
+
+func toJSONStatistics(row StatisticsRow) JSONStatistics {
+	stats := JSONStatistics{
+		Group:          row.Group,
+		Key:            row.Key,
+		RunningUnits:   row.RunningUnits,
+		CoveredUnits:   row.CoveredUnits,
+		PurchasedUnits: row.PurchasedUnits,
+		UsedUnits:      row.UsedUnits,
+	}
+	if row.RunningUnits != 0 {
+		stats.Coverage = aws.Float64(row.Coverage())
+	}
+	if row.PurchasedUnits != 0 {
+		stats.Utilization = aws.Float64(row.Utilization())
+	}
+	return stats
+}
+
+// writeJSON writes v as indented JSON, the format of every JSON output
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeJSONReport(w io.Writer, regionResults []RegionResult) error {
+	report := JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		Statistics:    []JSONStatistics{},
+		Regions:       []JSONRegionReport{},
+	}
+	for _, row := range summarizeStatistics(regionResults) {
+		report.Statistics = append(report.Statistics, toJSONStatistics(row))
+	}
+	for _, r := range regionResults {
+		region := toJSONRegionReport(r)
+		report.Summary.Covered += region.Summary.Covered
+		report.Summary.PartiallyCovered += region.Summary.PartiallyCovered
+		report.Summary.Uncovered += region.Summary.Uncovered
+		report.Summary.UnusedReservedInstances += region.Summary.UnusedReservedInstances
+		report.Regions = append(report.Regions, region)
+	}
+	return writeJSON(w, report)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_writeJSONReport(t *testing.T) {
+	regionResults := []RegionResult{
+		simulateFiles("testdata/describe-instances.json", "testdata/describe-reserved-instances.json"),
+		{Region: "us-east-1", Err: errors.New("access denied")},
+	}
+	regionResults[0].Region = "ap-northeast-1"
+
+	var buf bytes.Buffer
+	if err := writeJSONReport(&buf, regionResults); err != nil {
+		t.Fatalf("writeJSONReport() error = %v", err)
+	}
+	var got JSONReport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("writeJSONReport() writes invalid JSON: %v", err)
+	}
+
+	if got.SchemaVersion != JSONSchemaVersion {
+		t.Errorf("SchemaVersion = %v, want %v", got.SchemaVersion, JSONSchemaVersion)
+	}
+	wantSummary := JSONSummary{Covered: 1, PartiallyCovered: 1, Uncovered: 2, UnusedReservedInstances: 1}
+	if !reflect.DeepEqual(got.Summary, wantSummary) {
+		t.Errorf("Summary = %v, want %v", got.Summary, wantSummary)
+	}
+	wantTotal := JSONStatistics{Group: "total", RunningUnits: 22, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16}
+	if len(got.Statistics) == 0 || got.Statistics[0].Coverage == nil || got.Statistics[0].Utilization == nil {
+		t.Fatalf("Statistics = %v, want total with coverage and utilization", got.Statistics)
+	}
+	if total := got.Statistics[0]; *total.Utilization != 80 {
+		t.Errorf("Statistics[0].Utilization = %v, want %v", *total.Utilization, 80)
+	}
+	got.Statistics[0].Coverage, got.Statistics[0].Utilization = nil, nil
+	if !reflect.DeepEqual(got.Statistics[0], wantTotal) {
+		t.Errorf("Statistics[0] = %v, want %v", got.Statistics[0], wantTotal)
+	}
+	if len(got.Regions) != 2 {
+		t.Fatalf("len(Regions) = %v, want %v", len(got.Regions), 2)
+	}
+
+	region := got.Regions[0]
+	wantPartial := []JSONInstance{
+		{
+			InstanceId:       "i-000000000002",
+			InstanceType:     "m5.2xlarge",
+			Platform:         "Linux/UNIX",
+			Name:             "batch, nightly",
+			State:            "running",
+			AvailabilityZone: "ap-northeast-1c",
+			Tenancy:          "default",
+			Units:            16,
+			CoveredUnits:     12,
+		},
+	}
+	if !reflect.DeepEqual(region.PartiallyCoveredInstances, wantPartial) {
+		t.Errorf("PartiallyCoveredInstances = %v, want %v", region.PartiallyCoveredInstances, wantPartial)
+	}
+	wantAllocations := []JSONAllocation{
+		{ReservedInstancesId: "11111111-aaaa-bbbb-cccc-000000000001", InstanceId: "i-000000000001", Units: 4},
+		{ReservedInstancesId: "11111111-aaaa-bbbb-cccc-000000000001", InstanceId: "i-000000000002", Units: 12},
+	}
+	if !reflect.DeepEqual(region.Allocations, wantAllocations) {
+		t.Errorf("Allocations = %v, want %v", region.Allocations, wantAllocations)
+	}
+	if n := len(region.UnusedReservedInstances); n != 1 || region.UnusedReservedInstances[0].Scope != "ap-northeast-1a" {
+		t.Errorf("UnusedReservedInstances = %v, want the zonal c5.large RI", region.UnusedReservedInstances)
+	}
+
+	// sections are empty arrays, not null, in an errored region
+	failed := got.Regions[1]
+	if failed.Error != "access denied" || failed.CoveredInstances == nil || failed.Allocations == nil {
+		t.Errorf("Regions[1] = %v, want error with empty sections", failed)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MarkdownRenderer writes the report as Markdown tables for wiki pages
+type MarkdownRenderer struct {
+	ShowAllocations bool
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+func (r MarkdownRenderer) Render(w io.Writer, regionResults []RegionResult) error {
+	fmt.Fprintln(w, "# RI simulation report")
+	fmt.Fprintln(w)
+	if rows := summarizeStatistics(regionResults); rows != nil {
+		fmt.Fprintln(w, "| Group | Key | Running units | Covered units | Coverage | Purchased units | Used units | Utilization |")
+		fmt.Fprintln(w, "|---|---|---:|---:|---:|---:|---:|---:|")
+		for _, row := range rows {
+			fmt.Fprintf(w, "| %s | %s | %.2f | %.2f | %s | %.2f | %.2f | %s |\n",
+				row.Group,
+				markdownEscaper.Replace(row.Key),
+				row.RunningUnits,
+				row.CoveredUnits,
+				formatCoverage(row.Statistics),
+				row.PurchasedUnits,
+				row.UsedUnits,
+				formatUtilization(row.Statistics))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "| Region | Coverage | Utilization | Covered | Partially covered | Not covered | Unused RIs |")
+	fmt.Fprintln(w, "|---|---:|---:|---:|---:|---:|---:|")
+	for _, result := range regionResults {
+		if result.Err != nil {
+			fmt.Fprintf(w, "| %s | error | | | | | |\n", markdownEscaper.Replace(regionName(result.Region)))
+			continue
+		}
+		report := toJSONRegionReport(result)
+		stats := result.Results.Statistics()
+		fmt.Fprintf(w, "| %s | %s | %s | %d | %d | %d | %d |\n",
+			markdownEscaper.Replace(regionName(result.Region)),
+			formatCoverage(stats),
+			formatUtilization(stats),
+			report.Summary.Covered,
+			report.Summary.PartiallyCovered,
+			report.Summary.Uncovered,
+			report.Summary.UnusedReservedInstances)
+	}
+
+	for _, result := range regionResults {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "## %s\n", markdownEscaper.Replace(regionName(result.Region)))
+		fmt.Fprintln(w)
+		if result.Err != nil {
+			fmt.Fprintf(w, "Error: %s\n", markdownEscaper.Replace(result.Err.Error()))
+			continue
+		}
+		stats := result.Results.Statistics()
+		fmt.Fprintf(w, "Coverage: **%s** (%.2f / %.2f units)\n", formatCoverage(stats), stats.CoveredUnits, stats.RunningUnits)
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Utilization: **%s** (%.2f / %.2f units)\n", formatUtilization(stats), stats.UsedUnits, stats.PurchasedUnits)
+
+		report := toJSONRegionReport(result)
+		writeMarkdownInstances(w, "RI covered instances", report.CoveredInstances)
+		writeMarkdownInstances(w, "RI partially covered instances", report.PartiallyCoveredInstances)
+		writeMarkdownInstances(w, "RI *NOT* covered instances", report.UncoveredInstances)
+		writeMarkdownReservedInstances(w, "Purchased but not applied RI", report.UnusedReservedInstances)
+		writeMarkdownExchanges(w, report.ConvertibleExchanges)
+		if r.ShowAllocations {
+			writeMarkdownAllocations(w, report.Allocations)
+		}
+		if report.Accounts != nil {
+			writeMarkdownAccounts(w, report.Accounts)
+		}
+		if report.WhatIf != nil {
+			writeMarkdownWhatIf(w, *report.WhatIf)
+		}
+	}
+	return nil
+}
+
+func writeMarkdownInstances(w io.Writer, title string, instances []JSONInstance) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "### %s\n", title)
+	fmt.Fprintln(w)
+	if len(instances) == 0 {
+		fmt.Fprintln(w, "_None_")
+		return
+	}
+	fmt.Fprintln(w, "| Instance ID | Type | Platform | Name | State | AZ | Tenancy | Covered units |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|---:|")
+	for _, i := range instances {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s | %.2f/%.2f |\n",
+			markdownEscaper.Replace(i.InstanceId),
+			markdownEscaper.Replace(i.InstanceType),
+			markdownEscaper.Replace(i.Platform),
+			markdownEscaper.Replace(i.Name),
+			markdownEscaper.Replace(i.State),
+			markdownEscaper.Replace(i.AvailabilityZone),
+			markdownEscaper.Replace(i.Tenancy),
+			i.CoveredUnits,
+			i.Units)
+	}
+}
+
+func writeMarkdownReservedInstances(w io.Writer, title string, ris []JSONReservedInstance) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "### %s\n", title)
+	fmt.Fprintln(w)
+	if len(ris) == 0 {
+		fmt.Fprintln(w, "_None_")
+		return
+	}
+	fmt.Fprintln(w, "| RI ID | Type | Product | Scope | Tenancy | Class | Offering | Remaining count | Remaining units | End |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|---:|---:|---|")
+	for _, ri := range ris {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s | %s | %.2f/%d | %.2f/%.2f | %s |\n",
+			markdownEscaper.Replace(ri.ReservedInstancesId),
+			markdownEscaper.Replace(ri.InstanceType),
+			markdownEscaper.Replace(ri.ProductDescription),
+			markdownEscaper.Replace(ri.Scope),
+			markdownEscaper.Replace(ri.Tenancy),
+			markdownEscaper.Replace(ri.OfferingClass),
+			markdownEscaper.Replace(ri.OfferingType),
+			ri.RemainingCount,
+			ri.InstanceCount,
+			ri.RemainingUnits,
+			ri.Units,
+			formatTime(ri.End))
+	}
+}
+
+func writeMarkdownExchanges(w io.Writer, exchanges []JSONExchange) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "### Convertible RI exchanges")
+	fmt.Fprintln(w)
+	if len(exchanges) == 0 {
+		fmt.Fprintln(w, "_None_")
+		return
+	}
+	fmt.Fprintln(w, "| RI ID | Units | Count | Type | Product | Tenancy | Instances |")
+	fmt.Fprintln(w, "|---|---:|---:|---|---|---|---|")
+	for _, e := range exchanges {
+		fmt.Fprintf(w, "| %s | %.2f | %d | %s | %s | %s | %s |\n",
+			markdownEscaper.Replace(e.ReservedInstancesId),
+			e.Units,
+			e.Target.InstanceCount,
+			markdownEscaper.Replace(e.Target.InstanceType),
+			markdownEscaper.Replace(e.Target.ProductDescription),
+			markdownEscaper.Replace(e.Target.Tenancy),
+			markdownEscaper.Replace(strings.Join(e.Target.Instances, ", ")))
+	}
+}
+
+func writeMarkdownAllocations(w io.Writer, allocations []JSONAllocation) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "### RI allocations")
+	fmt.Fprintln(w)
+	if len(allocations) == 0 {
+		fmt.Fprintln(w, "_None_")
+		return
+	}
+	fmt.Fprintln(w, "| RI ID | Instance ID | Units |")
+	fmt.Fprintln(w, "|---|---|---:|")
+	for _, a := range allocations {
+		fmt.Fprintf(w, "| %s | %s | %.2f |\n",
+			markdownEscaper.Replace(a.ReservedInstancesId),
+			markdownEscaper.Replace(a.InstanceId),
+			a.Units)
+	}
+}
+
+func writeMarkdownAccounts(w io.Writer, accounts []AccountSummary) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "### Coverage per account")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Account | Covered | Partially covered | Not covered |")
+	fmt.Fprintln(w, "|---|---:|---:|---:|")
+	for _, a := range accounts {
+		fmt.Fprintf(w, "| %s | %d | %d | %d |\n", markdownEscaper.Replace(a.Account), a.Covered, a.Partial, a.Not)
+	}
+}
+
+func writeMarkdownWhatIf(w io.Writer, delta WhatIfDelta) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "### What-if compared with current RIs")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| | Current | What-if | Delta |")
+	fmt.Fprintln(w, "|---|---:|---:|---:|")
+	for _, row := range whatIfRows(delta) {
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", row.Label, row.Current, row.WhatIf, row.Delta)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+func TestMarkdownRenderer_Render(t *testing.T) {
+	regionResults := append(testRegionResults(), RegionResult{Region: "us-east-1", Err: errors.New("access denied")})
+
+	var buf bytes.Buffer
+	if err := (MarkdownRenderer{}).Render(&buf, regionResults); err != nil {
+		t.Fatalf("MarkdownRenderer.Render() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"| total |  | 22.00 | 16.00 | 72.7% | 20.00 | 16.00 | 80.0% |",
+		"| family | c5 | 0.00 | 0.00 | - | 4.00 | 0.00 | 0.0% |",
+		"| ap-northeast-1 | 72.7% | 80.0% | 1 | 1 | 2 | 1 |",
+		"| us-east-1 | error | | | | | |",
+		"Coverage: **72.7%** (16.00 / 22.00 units)",
+		"Utilization: **80.0%** (16.00 / 20.00 units)",
+		"| i-000000000002 | m5.2xlarge | Linux/UNIX | batch, nightly | running | ap-northeast-1c | default | 12.00/16.00 |",
+		"Error: access denied",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("MarkdownRenderer.Render() does not contain %q\n%s", want, got)
+		}
+	}
+}
+
+func TestMarkdownRenderer_Render_accounts(t *testing.T) {
+	r := testRegionResults()[0]
+	r.Accounts = map[string]string{
+		"i-000000000001": "111111111111",
+		"i-000000000002": "111111111111",
+		"i-000000000003": "222222222222",
+		"i-000000000004": "222222222222",
+	}
+	var buf bytes.Buffer
+	if err := (MarkdownRenderer{ShowAllocations: true}).Render(&buf, []RegionResult{r}); err != nil {
+		t.Fatalf("MarkdownRenderer.Render() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"### RI allocations",
+		"| 11111111-aaaa-bbbb-cccc-000000000001 | i-000000000002 | 12.00 |",
+		"### Coverage per account",
+		"| 111111111111 | 1 | 1 | 0 |",
+		"| 222222222222 | 0 | 0 | 2 |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("MarkdownRenderer.Render() does not contain %q\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	(MarkdownRenderer{}).Render(&buf, []RegionResult{r})
+	if strings.Contains(buf.String(), "### RI allocations") {
+		t.Errorf("MarkdownRenderer.Render() prints allocations without ShowAllocations\n%s", buf.String())
+	}
+}
+
+func TestMarkdownRenderer_Render_escape(t *testing.T) {
+	results := simurator.SimulatorResult{
+		UnmatchInstanceResults: []types.Instance{
+			{
+				InstanceId:   aws.String("i-000000000001"),
+				InstanceType: "t3.medium",
+				State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+				Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String("a|b")}},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	(MarkdownRenderer{}).Render(&buf, []RegionResult{{Region: "ap-northeast-1", Results: results}})
+	if !strings.Contains(buf.String(), `| a\|b |`) {
+		t.Errorf("MarkdownRenderer.Render() does not escape |\n%s", buf.String())
+	}
+}
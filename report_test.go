@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+func TestNewRenderer(t *testing.T) {
+	allocations := RenderOptions{ShowAllocations: true}
+	tests := []struct {
+		name    string
+		format  string
+		opts    RenderOptions
+		want    Renderer
+		wantErr bool
+	}{
+		{name: "text", format: "text", opts: allocations, want: TextRenderer{ShowAllocations: true}},
+		{name: "json", format: "json", opts: allocations, want: JSONRenderer{}},
+		{name: "csv", format: "csv", opts: RenderOptions{OutputDir: "out"}, want: CSVRenderer{Comma: ',', Ext: ".csv", OutputDir: "out"}},
+		{name: "tsv", format: "tsv", opts: RenderOptions{OutputDir: "out"}, want: CSVRenderer{Comma: '\t', Ext: ".tsv", OutputDir: "out"}},
+		{name: "csv with allocations", format: "csv", opts: allocations, wantErr: true},
+		{name: "tsv with allocations", format: "tsv", opts: allocations, wantErr: true},
+		{name: "markdown", format: "markdown", opts: allocations, want: MarkdownRenderer{ShowAllocations: true}},
+		{name: "html", format: "html", opts: allocations, want: HTMLRenderer{ShowAllocations: true}},
+		{name: "xml", format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRenderer(tt.format, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRenderer() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewRenderer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_summarizeStatistics(t *testing.T) {
+	second := testRegionResults()[0]
+	second.Region = "us-east-1"
+	regionResults := append(testRegionResults(), second, RegionResult{Region: "eu-west-1", Err: errors.New("access denied")})
+
+	tests := []struct {
+		name          string
+		regionResults []RegionResult
+		want          []StatisticsRow
+	}{
+		{
+			name:          "one region",
+			regionResults: testRegionResults(),
+			want: []StatisticsRow{
+				{Group: "total", Statistics: simurator.Statistics{RunningUnits: 22, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16}},
+				{Group: "family", Key: "c5", Statistics: simurator.Statistics{PurchasedUnits: 4}},
+				{Group: "family", Key: "m5", Statistics: simurator.Statistics{RunningUnits: 20, CoveredUnits: 16, PurchasedUnits: 16, UsedUnits: 16}},
+				{Group: "family", Key: "t3", Statistics: simurator.Statistics{RunningUnits: 2}},
+				{Group: "platform", Key: "Linux/UNIX", Statistics: simurator.Statistics{RunningUnits: 20, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16}},
+				{Group: "platform", Key: "Windows", Statistics: simurator.Statistics{RunningUnits: 2}},
+			},
+		},
+		{
+			name:          "several regions",
+			regionResults: regionResults,
+			want: []StatisticsRow{
+				{Group: "total", Statistics: simurator.Statistics{RunningUnits: 44, CoveredUnits: 32, PurchasedUnits: 40, UsedUnits: 32}},
+				{Group: "region", Key: "ap-northeast-1", Statistics: simurator.Statistics{RunningUnits: 22, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16}},
+				{Group: "region", Key: "us-east-1", Statistics: simurator.Statistics{RunningUnits: 22, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16}},
+				{Group: "family", Key: "c5", Statistics: simurator.Statistics{PurchasedUnits: 8}},
+				{Group: "family", Key: "m5", Statistics: simurator.Statistics{RunningUnits: 40, CoveredUnits: 32, PurchasedUnits: 32, UsedUnits: 32}},
+				{Group: "family", Key: "t3", Statistics: simurator.Statistics{RunningUnits: 4}},
+				{Group: "platform", Key: "Linux/UNIX", Statistics: simurator.Statistics{RunningUnits: 40, CoveredUnits: 32, PurchasedUnits: 40, UsedUnits: 32}},
+				{Group: "platform", Key: "Windows", Statistics: simurator.Statistics{RunningUnits: 4}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizeStatistics(tt.regionResults); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarizeStatistics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatCoverage(t *testing.T) {
+	tests := []struct {
+		stats           simurator.Statistics
+		wantCoverage    string
+		wantUtilization string
+	}{
+		{stats: simurator.Statistics{RunningUnits: 22, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16}, wantCoverage: "72.7%", wantUtilization: "80.0%"},
+		{stats: simurator.Statistics{}, wantCoverage: "-", wantUtilization: "-"},
+	}
+	for _, tt := range tests {
+		if got := formatCoverage(tt.stats); got != tt.wantCoverage {
+			t.Errorf("formatCoverage() = %v, want %v", got, tt.wantCoverage)
+		}
+		if got := formatUtilization(tt.stats); got != tt.wantUtilization {
+			t.Errorf("formatUtilization() = %v, want %v", got, tt.wantUtilization)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// TextRenderer writes the fixed-width text report
+type TextRenderer struct {
+	ShowAllocations bool
+}
+
+func (r TextRenderer) Render(w io.Writer, regionResults []RegionResult) error {
+	if rows := summarizeStatistics(regionResults); rows != nil {
+		printStatistics(w, rows)
+		fmt.Fprintln(w)
+	}
+	for _, result := range regionResults {
+		if len(regionResults) > 1 {
+			fmt.Fprintf(w, "##### %s #####\n", result.Region)
+		}
+		if result.Err != nil {
+			// the error itself is reported on errStream by Run
+			continue
+		}
+		printReport(w, result, r.ShowAllocations)
+		if result.Accounts != nil {
+			fmt.Fprintln(w)
+			printAccountSummary(w, result.Results, result.Accounts)
+		}
+		if delta := whatIfDelta(result); delta != nil {
+			fmt.Fprintln(w)
+			printWhatIf(w, *delta)
+		}
+		if len(regionResults) > 1 {
+			fmt.Fprintln(w)
+		}
+	}
+	if len(regionResults) > 1 {
+		printSummary(w, regionResults)
+	}
+	return nil
+}
+
+func printStatistics(w io.Writer, rows []StatisticsRow) {
+	fmt.Fprintln(w, "=== RI coverage and utilization ===")
+	fmt.Fprintf(w, "%-8s %-16s %9s %9s %8s %9s %9s %11s\n",
+		"Group", "Key", "Running", "Covered", "Coverage", "Purchased", "Used", "Utilization")
+	for _, row := range rows {
+		fmt.Fprintf(w, "%-8s %-16s %9.2f %9.2f %8s %9.2f %9.2f %11s\n",
+			row.Group,
+			row.Key,
+			row.RunningUnits,
+			row.CoveredUnits,
+			formatCoverage(row.Statistics),
+			row.PurchasedUnits,
+			row.UsedUnits,
+			formatUtilization(row.Statistics))
+	}
+}
+
+func printWhatIf(w io.Writer, delta WhatIfDelta) {
+	fmt.Fprintln(w, "=== What-if compared with current RIs ===")
+	fmt.Fprintf(w, "%-18s %10s %10s %10s\n", "", "Current", "What-if", "Delta")
+	for _, row := range whatIfRows(delta) {
+		fmt.Fprintf(w, "%-18s %10s %10s %10s\n", row.Label, row.Current, row.WhatIf, row.Delta)
+	}
+}
+
+// formatEnd formats the end of the term; hypothetical RIs have none
+func formatEnd(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.String()
+}
+
+func printSummary(w io.Writer, regionResults []RegionResult) {
+	fmt.Fprintln(w, "=== Summary ===")
+	fmt.Fprintf(w, "%-16s %8s %8s %8s %8s\n", "Region", "Covered", "Partial", "Not", "Unused")
+	for _, r := range regionResults {
+		if r.Err != nil {
+			fmt.Fprintf(w, "%-16s %s\n", r.Region, "error")
+			continue
+		}
+		listed := r.Listed()
+		fmt.Fprintf(w, "%-16s %8d %8d %8d %8d\n",
+			r.Region,
+			len(listed.MatchInstanceResults),
+			len(listed.PartialMatchInstanceResults),
+			len(listed.UnmatchInstanceResults),
+			len(listed.UnmatchReservedInstanceResults))
+	}
+}
+
+func printAccountSummary(w io.Writer, results simurator.SimulatorResult, accounts map[string]string) {
+	fmt.Fprintln(w, "=== Coverage per account ===")
+	fmt.Fprintf(w, "%-16s %8s %8s %8s\n", "Account", "Covered", "Partial", "Not")
+	for _, a := range summarizeAccounts(results, accounts) {
+		fmt.Fprintf(w, "%-16s %8d %8d %8d\n", a.Account, a.Covered, a.Partial, a.Not)
+	}
+}
+
+// printReport lists the instances in the -state filter, while exchanges
+// use all instances as the statistics do
+func printReport(w io.Writer, r RegionResult, showAllocations bool) {
+	results := r.Listed()
+	fmt.Fprintln(w, "=== RI covered instances ===")
+	for _, i := range results.MatchInstanceResults {
+		fmt.Fprintf(w, "%-20s %-12s %-10s %-9s %-20s %-s\n",
+			aws.ToString(i.InstanceId),
+			i.InstanceType,
+			i.Platform,
+			simurator.InstanceTenancy(i),
+			ToName(i.Tags),
+			ToState(i))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "=== RI partially covered instances ===")
+	for _, i := range results.PartialMatchInstanceResults {
+		fmt.Fprintf(w, "%-20s %-12s %-10s %-9s %-20s %-10s %6.2f/%.2f\n",
+			aws.ToString(i.InstanceId),
+			i.InstanceType,
+			i.Platform,
+			simurator.InstanceTenancy(i.Instance),
+			ToName(i.Tags),
+			ToState(i.Instance),
+			i.CoveredUnits,
+			i.Units)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "=== RI *NOT* covered instances ===")
+	for _, i := range results.UnmatchInstanceResults {
+		fmt.Fprintf(w, "%-20s %-12s %-10s %-9s %-20s %-s\n",
+			aws.ToString(i.InstanceId),
+			i.InstanceType,
+			i.Platform,
+			simurator.InstanceTenancy(i),
+			ToName(i.Tags),
+			ToState(i))
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "=== Purchased but not applied RI ===")
+	for _, ri := range results.UnmatchReservedInstanceResults {
+		fmt.Fprintf(w, "%20s %-12s %-10s %-16s %-9s %-11s %-12s %5.2f/%-3d %6.2f/%-6.2f %v\n",
+			"",
+			ri.InstanceType,
+			ri.ProductDescription,
+			ToScope(ri.ReservedInstances),
+			simurator.ReservedInstanceTenancy(ri.ReservedInstances),
+			ri.OfferingClass,
+			ri.OfferingType,
+			ri.RemainingCount(),
+			aws.ToInt32(ri.InstanceCount),
+			ri.RemainingUnits,
+			ri.Units,
+			formatEnd(ri.End))
+	}
+	fmt.Fprintln(w)
+	printExchanges(w, r.Results)
+
+	if showAllocations {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "=== RI allocations ===")
+		for _, ri := range results.ReservedInstanceResults {
+			allocations := results.AllocationsOf(aws.ToString(ri.ReservedInstancesId))
+			if len(allocations) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%-36s %-12s %-10s %6.2f/%.2f\n",
+				aws.ToString(ri.ReservedInstancesId),
+				ri.InstanceType,
+				ri.ProductDescription,
+				ri.UsedUnits,
+				ri.Units)
+			for _, a := range allocations {
+				fmt.Fprintf(w, "    %-20s %6.2f\n", a.InstanceId, a.Units)
+			}
+		}
+	}
+}
+
+func printExchanges(w io.Writer, results simurator.SimulatorResult) {
+	fmt.Fprintln(w, "=== Convertible RI exchanges ===")
+	for _, e := range results.ConvertibleExchanges() {
+		fmt.Fprintf(w, "%-36s %6.2f units -> %dx %-12s %-10s %-9s %s\n",
+			e.ReservedInstancesId,
+			e.Units,
+			e.Target.InstanceCount,
+			e.Target.InstanceType,
+			e.Target.ProductDescription,
+			e.Target.Tenancy,
+			strings.Join(e.Target.Instances, ", "))
+	}
+}
@@ -0,0 +1,51 @@
+package simurator
+
+import (
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Exchange proposes to exchange unused units of a convertible RI for
+// a reservation covering uncovered running instances.
+//
+// Units are normalized units, which do not reflect prices: AWS requires the
+// new reservation to be of equal or greater value than the exchanged one.
+type Exchange struct {
+	ReservedInstancesId string
+	// unused units of the convertible RI to exchange
+	Units float64
+	// the reservation to exchange for
+	Target Recommendation
+}
+
+// ConvertibleExchanges proposes exchanges of the unused units of convertible
+// RIs for the uncovered running instances. The groups of instances with the
+// most uncovered units are covered first, as in Recommend.
+func (r SimulatorResult) ConvertibleExchanges() []Exchange {
+	var exchanges []Exchange
+	groups := r.recommendGroups(RecommendOptions{})
+	for _, ri := range r.ReservedInstanceResults {
+		if !IsConvertible(ri.ReservedInstances) {
+			continue
+		}
+		remaining := ri.RemainingUnits
+		for _, group := range groups {
+			if remaining <= 0 {
+				break
+			}
+			if group.uncoveredUnits <= 0 {
+				continue
+			}
+			target := group.recommend(math.Min(remaining, group.uncoveredUnits))
+			group.consume(target.UncoveredUnits)
+			remaining -= target.UncoveredUnits
+			exchanges = append(exchanges, Exchange{
+				ReservedInstancesId: aws.ToString(ri.ReservedInstancesId),
+				Units:               target.UncoveredUnits,
+				Target:              target,
+			})
+		}
+	}
+	return exchanges
+}
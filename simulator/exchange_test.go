@@ -0,0 +1,63 @@
+package simurator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestSimulatorResult_ConvertibleExchanges(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	ri := func(id string, class types.OfferingClassType, t types.InstanceType, count int32) types.ReservedInstances {
+		return types.ReservedInstances{
+			ReservedInstancesId: aws.String(id),
+			InstanceType:        t,
+			InstanceCount:       aws.Int32(count),
+			ProductDescription:  "Linux/UNIX",
+			Scope:               types.ScopeRegional,
+			OfferingClass:       class,
+		}
+	}
+	sim := Simulator{
+		Instances: []types.Instance{
+			{InstanceId: aws.String("m5"), InstanceType: "m5.large", State: running},
+			{InstanceId: aws.String("c5"), InstanceType: "c5.xlarge", State: running},
+			{InstanceId: aws.String("windows"), InstanceType: "t3.medium", Platform: "windows", State: running},
+			{InstanceId: aws.String("stopped"), InstanceType: "r5.large", State: &types.InstanceState{Name: types.InstanceStateNameStopped}},
+		},
+		ReservedInstances: []types.ReservedInstances{
+			// 8 units, 4 used by m5
+			ri("convertible-1", types.OfferingClassTypeConvertible, "m5.large", 2),
+			// standard RIs cannot be exchanged
+			ri("standard", types.OfferingClassTypeStandard, "r5.large", 1),
+			ri("convertible-2", types.OfferingClassTypeConvertible, "r5.large", 2),
+		},
+	}
+	results, err := sim.Simulate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Exchange{
+		{
+			ReservedInstancesId: "convertible-1",
+			Units:               4,
+			Target:              Recommendation{InstanceType: "c5.large", ProductDescription: "Linux/UNIX", Tenancy: "default", InstanceCount: 1, Units: 4, UncoveredUnits: 4, Instances: []string{"c5"}},
+		},
+		{
+			ReservedInstancesId: "convertible-2",
+			Units:               4,
+			Target:              Recommendation{InstanceType: "c5.large", ProductDescription: "Linux/UNIX", Tenancy: "default", InstanceCount: 1, Units: 4, UncoveredUnits: 4, Instances: []string{"c5"}},
+		},
+		{
+			ReservedInstancesId: "convertible-2",
+			Units:               2,
+			Target:              Recommendation{InstanceType: "t3.medium", ProductDescription: "Windows", Tenancy: "default", InstanceCount: 1, Units: 2, UncoveredUnits: 2, Instances: []string{"windows"}},
+		},
+	}
+	if got := results.ConvertibleExchanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SimulatorResult.ConvertibleExchanges() = %+v, want %+v", got, want)
+	}
+}
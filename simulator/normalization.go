@@ -0,0 +1,92 @@
+package simurator
+
+// see
+// Instance size flexibility for regional Reserved Instances
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/apply_ri.html
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+var normalizationFactors = map[string]float64{
+	"nano":   0.25,
+	"micro":  0.5,
+	"small":  1,
+	"medium": 2,
+	"large":  4,
+	"xlarge": 8,
+}
+
+// bare metal instances are normalized as the largest size in the family
+var metalNormalizationFactors = map[string]float64{
+	"a1":   32,
+	"c5":   192,
+	"c5d":  192,
+	"c5n":  144,
+	"c6g":  128,
+	"c6gd": 128,
+	"c6i":  256,
+	"i3":   128,
+	"i3en": 192,
+	"m5":   192,
+	"m5d":  192,
+	"m5dn": 192,
+	"m5n":  192,
+	"m5zn": 96,
+	"m6g":  128,
+	"m6gd": 128,
+	"m6i":  256,
+	"r5":   192,
+	"r5b":  192,
+	"r5d":  192,
+	"r5dn": 192,
+	"r5n":  192,
+	"r6g":  128,
+	"r6gd": 128,
+	"r6i":  256,
+	"x2gd": 128,
+	"z1d":  96,
+}
+
+// SplitInstanceType splits "m5.xlarge" into "m5" and "xlarge".
+func SplitInstanceType(t types.InstanceType) (family, size string) {
+	s := string(t)
+	if n := strings.Index(s, "."); n >= 0 {
+		return s[:n], s[n+1:]
+	}
+	return s, ""
+}
+
+// NormalizationFactor returns the normalization factor of the instance type.
+// ok is false when the size is unknown.
+func NormalizationFactor(t types.InstanceType) (factor float64, ok bool) {
+	family, size := SplitInstanceType(t)
+	if size == "metal" {
+		factor, ok = metalNormalizationFactors[family]
+		return factor, ok
+	}
+	if factor, ok = normalizationFactors[size]; ok {
+		return factor, true
+	}
+	// 2xlarge, 4xlarge, ... 32xlarge
+	if strings.HasSuffix(size, "xlarge") {
+		n, err := strconv.Atoi(strings.TrimSuffix(size, "xlarge"))
+		if err == nil && n > 0 {
+			return float64(n) * normalizationFactors["xlarge"], true
+		}
+	}
+	return 0, false
+}
+
+// Units returns the normalized units of the instance type.
+// An unknown size counts as 1 unit, which is only ever matched by
+// a reservation of exactly the same instance type.
+func Units(t types.InstanceType) float64 {
+	if factor, ok := NormalizationFactor(t); ok {
+		return factor
+	}
+	return 1
+}
@@ -0,0 +1,33 @@
+package simurator
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestNormalizationFactor(t *testing.T) {
+	tests := []struct {
+		name       string
+		t          types.InstanceType
+		wantFactor float64
+		wantOk     bool
+	}{
+		{t: "t3.nano", wantFactor: 0.25, wantOk: true},
+		{t: "m5.large", wantFactor: 4, wantOk: true},
+		{t: "m5.xlarge", wantFactor: 8, wantOk: true},
+		{t: "m5.2xlarge", wantFactor: 16, wantOk: true},
+		{t: "m6i.32xlarge", wantFactor: 256, wantOk: true},
+		{t: "m5.metal", wantFactor: 192, wantOk: true},
+		{t: "u-6tb1.metal", wantFactor: 0, wantOk: false},
+		{t: "m5.huge", wantFactor: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.t), func(t *testing.T) {
+			gotFactor, gotOk := NormalizationFactor(tt.t)
+			if gotFactor != tt.wantFactor || gotOk != tt.wantOk {
+				t.Errorf("NormalizationFactor() = %v, %v, want %v, %v", gotFactor, gotOk, tt.wantFactor, tt.wantOk)
+			}
+		})
+	}
+}
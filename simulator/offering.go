@@ -0,0 +1,12 @@
+package simurator
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// IsConvertible reports whether the RI is a convertible RI.
+// Standard and convertible RIs are applied to instances alike, but only
+// convertible RIs can be exchanged for another family, platform or tenancy.
+func IsConvertible(ri types.ReservedInstances) bool {
+	return ri.OfferingClass == types.OfferingClassTypeConvertible
+}
@@ -0,0 +1,104 @@
+package simurator
+
+// Allocation order
+//
+// The simulator applies reservations in the following order, which mirrors
+// how AWS applies RI discounts on the bill.
+//
+//  1. With consolidated billing, RIs are applied to instances of the
+//     account that purchased them first, then to the other accounts.
+//     Zonal RIs are applied to the owner account only, as AZ names are
+//     mapped to physical zones independently for each account.
+//  2. Instances are processed from the smallest normalized size to the
+//     largest, so that size-flexible RIs cover small instances fully and
+//     larger instances only partially.
+//     Ties are broken by launch time (older first), then by instance ID.
+//  3. For each instance, zonal RIs are applied before regional RIs.
+//  4. Among regional RIs, an RI of exactly the same instance type is applied
+//     before size-flexible RIs of other sizes in the family, and smaller RI
+//     sizes are applied before larger ones.
+//  5. AWS does not document which of several equivalent reservations is
+//     used. Standard RIs are applied before convertible RIs, so that unused
+//     units are left on convertible RIs, which can be exchanged; remaining
+//     ties are broken by ReservedInstancesId.
+//
+// see
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/apply_ri.html
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// instanceOrder returns the indices of instances in allocation order.
+func instanceOrder(instances []types.Instance) []int {
+	order := make([]int, len(instances))
+	for n := range order {
+		order[n] = n
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		p, q := instances[order[a]], instances[order[b]]
+		if up, uq := Units(p.InstanceType), Units(q.InstanceType); up != uq {
+			return up < uq
+		}
+		if tp, tq := aws.ToTime(p.LaunchTime), aws.ToTime(q.LaunchTime); !tp.Equal(tq) {
+			return tp.Before(tq)
+		}
+		return aws.ToString(p.InstanceId) < aws.ToString(q.InstanceId)
+	})
+	return order
+}
+
+// reservedInstanceOrder returns the indices of reservations sorted by
+// scope, size, offering class and ID (rules 3 to 5, without the preference
+// of the exact instance type). It is sorted once per simulation.
+func reservedInstanceOrder(ris []types.ReservedInstances) []int {
+	order := make([]int, len(ris))
+	units := make([]float64, len(ris))
+	for n, ri := range ris {
+		order[n] = n
+		units[n] = Units(ri.InstanceType)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		p, q := ris[order[a]], ris[order[b]]
+		if zp, zq := is_zonal(p), is_zonal(q); zp != zq {
+			return zp
+		}
+		if up, uq := units[order[a]], units[order[b]]; up != uq {
+			return up < uq
+		}
+		if cp, cq := IsConvertible(p), IsConvertible(q); cp != cq {
+			return cq
+		}
+		return aws.ToString(p.ReservedInstancesId) < aws.ToString(q.ReservedInstancesId)
+	})
+	return order
+}
+
+// instanceTypeOrder returns the indices of reservations in the order they
+// are applied to instances of type t, given the order of
+// reservedInstanceOrder: RIs of exactly type t are moved before the other
+// RIs of the same scope. RIs of other families are left out, as they never
+// apply.
+func instanceTypeOrder(ris []types.ReservedInstances, order []int, t types.InstanceType) []int {
+	family, _ := SplitInstanceType(t)
+	var zonal, regional, flexible []int
+	for _, n := range order {
+		ri := ris[n]
+		switch {
+		case ri.InstanceType == t && is_zonal(ri):
+			zonal = append(zonal, n)
+		case ri.InstanceType == t:
+			regional = append(regional, n)
+		case is_zonal(ri):
+			// zonal RIs apply only to the exact instance type
+		default:
+			if f, _ := SplitInstanceType(ri.InstanceType); f == family {
+				flexible = append(flexible, n)
+			}
+		}
+	}
+	return append(append(zonal, regional...), flexible...)
+}
@@ -0,0 +1,82 @@
+package simurator
+
+// see
+// Billing information fields (usage operation)
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/billing-info-fields.html
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	ProductLinux   = "Linux/UNIX"
+	ProductWindows = "Windows"
+)
+
+// products maps the usage operation of an instance to the product
+// description of the RIs applicable to it
+var products = map[string]string{
+	"RunInstances":      ProductLinux,
+	"RunInstances:0002": ProductWindows,
+	"RunInstances:0006": "Windows with SQL Server Standard",
+	"RunInstances:0102": "Windows with SQL Server Enterprise",
+	"RunInstances:0202": "Windows with SQL Server Web",
+	"RunInstances:0800": "Windows BYOL",
+	"RunInstances:0004": "Linux with SQL Server Standard",
+	"RunInstances:0100": "Linux with SQL Server Enterprise",
+	"RunInstances:0200": "Linux with SQL Server Web",
+	"RunInstances:0010": "Red Hat Enterprise Linux",
+	"RunInstances:0014": "Red Hat Enterprise Linux with SQL Server Standard",
+	"RunInstances:0110": "Red Hat Enterprise Linux with SQL Server Enterprise",
+	"RunInstances:0210": "Red Hat Enterprise Linux with SQL Server Web",
+	"RunInstances:1010": "Red Hat Enterprise Linux with HA",
+	"RunInstances:1014": "Red Hat Enterprise Linux with HA and SQL Server Standard",
+	"RunInstances:1110": "Red Hat Enterprise Linux with HA and SQL Server Enterprise",
+	"RunInstances:000g": "SUSE Linux",
+	"RunInstances:00g0": "Red Hat BYOL Linux",
+}
+
+// vpcSuffix is appended to the product description of RIs
+// purchased for EC2-Classic accounts with a VPC
+const vpcSuffix = " (Amazon VPC)"
+
+// InstanceProduct returns the product of the instance, e.g.
+// "Red Hat Enterprise Linux" or "Windows with SQL Server Standard".
+// It is taken from PlatformDetails, then UsageOperation, then Platform,
+// where an empty Platform means "Linux/UNIX".
+func InstanceProduct(i types.Instance) string {
+	if d := aws.ToString(i.PlatformDetails); d != "" {
+		return d
+	}
+	if p, ok := products[aws.ToString(i.UsageOperation)]; ok {
+		return p
+	}
+	switch {
+	case i.Platform == "":
+		return ProductLinux
+	case strings.EqualFold(string(i.Platform), string(types.PlatformValuesWindows)):
+		return ProductWindows
+	}
+	return string(i.Platform)
+}
+
+// IsProduct reports whether the description is a known product
+// description of RIs, with or without the " (Amazon VPC)" suffix.
+func IsProduct(description string) bool {
+	description = strings.TrimSuffix(description, vpcSuffix)
+	for _, p := range products {
+		if p == description {
+			return true
+		}
+	}
+	return false
+}
+
+// ReservedInstanceProduct returns the product description of the RI
+// without the " (Amazon VPC)" suffix, e.g. "Linux/UNIX".
+func ReservedInstanceProduct(ri types.ReservedInstances) string {
+	return strings.TrimSuffix(string(ri.ProductDescription), vpcSuffix)
+}
@@ -0,0 +1,86 @@
+package simurator
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func TestInstanceProduct(t *testing.T) {
+	tests := []struct {
+		name string
+		i    types.Instance
+		want string
+	}{
+		{name: "empty platform", i: types.Instance{}, want: "Linux/UNIX"},
+		{name: "windows", i: types.Instance{Platform: "windows"}, want: "Windows"},
+		{
+			name: "platform details",
+			i:    types.Instance{PlatformDetails: aws.String("SUSE Linux"), UsageOperation: aws.String("RunInstances")},
+			want: "SUSE Linux",
+		},
+		{
+			name: "usage operation",
+			i:    types.Instance{Platform: "windows", UsageOperation: aws.String("RunInstances:0202")},
+			want: "Windows with SQL Server Web",
+		},
+		{
+			name: "unknown usage operation",
+			i:    types.Instance{UsageOperation: aws.String("RunInstances:9999")},
+			want: "Linux/UNIX",
+		},
+		{
+			// already normalized by InstanceProduct
+			name: "product as platform",
+			i:    types.Instance{Platform: "Red Hat Enterprise Linux"},
+			want: "Red Hat Enterprise Linux",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InstanceProduct(tt.i); got != tt.want {
+				t.Errorf("InstanceProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReservedInstanceProduct(t *testing.T) {
+	tests := []struct {
+		description types.RIProductDescription
+		want        string
+	}{
+		{description: "Linux/UNIX", want: "Linux/UNIX"},
+		{description: "Linux/UNIX (Amazon VPC)", want: "Linux/UNIX"},
+		{description: "Windows with SQL Server Enterprise (Amazon VPC)", want: "Windows with SQL Server Enterprise"},
+		{description: "Red Hat Enterprise Linux", want: "Red Hat Enterprise Linux"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.description), func(t *testing.T) {
+			if got := ReservedInstanceProduct(types.ReservedInstances{ProductDescription: tt.description}); got != tt.want {
+				t.Errorf("ReservedInstanceProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsProduct(t *testing.T) {
+	tests := []struct {
+		description string
+		want        bool
+	}{
+		{description: "Linux/UNIX", want: true},
+		{description: "Linux/UNIX (Amazon VPC)", want: true},
+		{description: "Red Hat Enterprise Linux with HA", want: true},
+		{description: "linux", want: false},
+		{description: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := IsProduct(tt.description); got != tt.want {
+				t.Errorf("IsProduct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package simurator
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// RecommendOptions configures SimulatorResult.Recommend.
+type RecommendOptions struct {
+	// percentage of running units to cover, e.g. 80
+	TargetCoverage float64
+	// instances launched less than MinAge before Now are not reserved,
+	// as they may be short-lived
+	MinAge time.Duration
+	Now    time.Time
+	// recommend zonal RIs in the AZ of the instances instead of regional RIs
+	Zonal bool
+}
+
+// Recommendation is a reservation proposed for uncovered running instances.
+type Recommendation struct {
+	InstanceType       types.InstanceType
+	ProductDescription string
+	Tenancy            types.Tenancy
+	// zonal RI of the Availability Zone, regional RI if empty
+	AvailabilityZone string
+	InstanceCount    int32
+	// normalized units of the reservation
+	Units float64
+	// uncovered units of Instances the reservation is for
+	UncoveredUnits float64
+	Instances      []string
+}
+
+// recommendKey groups instances that can share a reservation
+type recommendKey struct {
+	// family of size flexible instances, instance type otherwise
+	instanceType     string
+	product          string
+	tenancy          types.Tenancy
+	availabilityZone string
+	sizeFlexible     bool
+}
+
+type recommendInstance struct {
+	id             string
+	instanceType   types.InstanceType
+	uncoveredUnits float64
+}
+
+type recommendGroup struct {
+	key            recommendKey
+	instances      []recommendInstance
+	uncoveredUnits float64
+	// instance types of the group, the sizes a size flexible RI may have
+	instanceTypes []types.InstanceType
+}
+
+// Recommend proposes a minimal set of reservations covering the uncovered
+// running instances until TargetCoverage is reached. Instances of a family
+// share a size flexible RI where AWS applies size flexibility; the groups
+// with the most uncovered units are reserved first.
+func (r SimulatorResult) Recommend(opts RecommendOptions) []Recommendation {
+	stats := r.Statistics()
+	need := stats.RunningUnits*opts.TargetCoverage/100 - stats.CoveredUnits
+	if need <= 0 {
+		return nil
+	}
+
+	var recommendations []Recommendation
+	for _, group := range r.recommendGroups(opts) {
+		if need <= 0 {
+			break
+		}
+		recommendation := group.recommend(math.Min(need, group.uncoveredUnits))
+		need -= recommendation.UncoveredUnits
+		recommendations = append(recommendations, recommendation)
+	}
+	return recommendations
+}
+
+func (r SimulatorResult) recommendGroups(opts RecommendOptions) []*recommendGroup {
+	groups := map[recommendKey]*recommendGroup{}
+	add := func(i types.Instance, uncovered float64) {
+		if i.State == nil || i.State.Name != types.InstanceStateNameRunning {
+			return
+		}
+		if opts.MinAge > 0 && i.LaunchTime != nil && i.LaunchTime.After(opts.Now.Add(-opts.MinAge)) {
+			return
+		}
+		key := recommendKeyOf(i, opts.Zonal)
+		group, ok := groups[key]
+		if !ok {
+			group = &recommendGroup{key: key}
+			groups[key] = group
+		}
+		id := ""
+		if i.InstanceId != nil {
+			id = *i.InstanceId
+		}
+		group.instances = append(group.instances, recommendInstance{id, i.InstanceType, uncovered})
+		group.uncoveredUnits += uncovered
+		if !containsInstanceType(group.instanceTypes, i.InstanceType) {
+			group.instanceTypes = append(group.instanceTypes, i.InstanceType)
+		}
+	}
+	for _, i := range r.PartialMatchInstanceResults {
+		add(i.Instance, i.Units-i.CoveredUnits)
+	}
+	for _, i := range r.UnmatchInstanceResults {
+		add(i, Units(i.InstanceType))
+	}
+
+	sorted := make([]*recommendGroup, 0, len(groups))
+	for _, group := range groups {
+		// larger instances first, so that they are listed before smaller ones
+		sort.SliceStable(group.instances, func(a, b int) bool {
+			return group.instances[a].uncoveredUnits > group.instances[b].uncoveredUnits
+		})
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(a, b int) bool {
+		ga, gb := sorted[a], sorted[b]
+		if ga.uncoveredUnits != gb.uncoveredUnits {
+			return ga.uncoveredUnits > gb.uncoveredUnits
+		}
+		ka, kb := ga.key, gb.key
+		if ka.instanceType != kb.instanceType {
+			return ka.instanceType < kb.instanceType
+		}
+		if ka.product != kb.product {
+			return ka.product < kb.product
+		}
+		if ka.tenancy != kb.tenancy {
+			return ka.tenancy < kb.tenancy
+		}
+		return ka.availabilityZone < kb.availabilityZone
+	})
+	return sorted
+}
+
+func recommendKeyOf(i types.Instance, zonal bool) recommendKey {
+	key := recommendKey{
+		instanceType: string(i.InstanceType),
+		product:      InstanceProduct(i),
+		tenancy:      InstanceTenancy(i),
+	}
+	if zonal {
+		if i.Placement != nil && i.Placement.AvailabilityZone != nil {
+			key.availabilityZone = *i.Placement.AvailabilityZone
+		}
+		return key
+	}
+	if _, ok := NormalizationFactor(i.InstanceType); ok && key.product == ProductLinux && key.tenancy == types.TenancyDefault {
+		key.instanceType, _ = SplitInstanceType(i.InstanceType)
+		key.sizeFlexible = true
+	}
+	return key
+}
+
+// recommend returns the reservation covering units of the group
+func (g *recommendGroup) recommend(units float64) Recommendation {
+	recommendation := Recommendation{
+		InstanceType:       types.InstanceType(g.key.instanceType),
+		ProductDescription: g.key.product,
+		Tenancy:            g.key.tenancy,
+		AvailabilityZone:   g.key.availabilityZone,
+	}
+	factor := Units(recommendation.InstanceType)
+	if g.key.sizeFlexible {
+		recommendation.InstanceType, factor = g.size(units)
+	}
+	recommendation.InstanceCount = int32(math.Ceil(units/factor - 1e-9))
+	recommendation.Units = float64(recommendation.InstanceCount) * factor
+
+	for _, i := range g.instances {
+		if recommendation.UncoveredUnits >= units {
+			break
+		}
+		recommendation.UncoveredUnits += math.Min(i.uncoveredUnits, units-recommendation.UncoveredUnits)
+		recommendation.Instances = append(recommendation.Instances, i.id)
+	}
+	return recommendation
+}
+
+// consume removes units from the uncovered units of the group, from the
+// instances in the order recommend lists them
+func (g *recommendGroup) consume(units float64) {
+	g.uncoveredUnits -= units
+	for len(g.instances) > 0 && units > 0 {
+		n := math.Min(g.instances[0].uncoveredUnits, units)
+		g.instances[0].uncoveredUnits -= n
+		units -= n
+		if g.instances[0].uncoveredUnits <= 0 {
+			g.instances = g.instances[1:]
+		}
+	}
+}
+
+// recommendSizes are offered in addition to the sizes of the instances of
+// a group, in the families where they exist (see smallestSizes)
+var recommendSizes = []string{"large", "xlarge"}
+
+// smallestSizes are the smallest sizes of the families without a large size
+var smallestSizes = map[string]string{
+	"d2":     "xlarge",
+	"d3":     "xlarge",
+	"d3en":   "xlarge",
+	"dl1":    "24xlarge",
+	"f1":     "2xlarge",
+	"g3":     "4xlarge",
+	"g3s":    "xlarge",
+	"g4ad":   "xlarge",
+	"g4dn":   "xlarge",
+	"g5":     "xlarge",
+	"g5g":    "xlarge",
+	"h1":     "2xlarge",
+	"hpc6a":  "48xlarge",
+	"inf1":   "xlarge",
+	"p2":     "xlarge",
+	"p3":     "2xlarge",
+	"p3dn":   "24xlarge",
+	"p4d":    "24xlarge",
+	"trn1":   "2xlarge",
+	"vt1":    "3xlarge",
+	"x1":     "16xlarge",
+	"x1e":    "xlarge",
+	"x2idn":  "16xlarge",
+	"x2iedn": "xlarge",
+	"x2iezn": "2xlarge",
+}
+
+// size picks the instance size that covers units with the least unused
+// units, preferring larger sizes (fewer RIs) on a tie.
+func (g *recommendGroup) size(units float64) (types.InstanceType, float64) {
+	candidates := make([]types.InstanceType, 0, len(recommendSizes)+len(g.instanceTypes))
+	family := g.key.instanceType
+	for _, size := range recommendSizes {
+		t := types.InstanceType(family + "." + size)
+		if smallest, ok := smallestSizes[family]; ok && Units(t) < Units(types.InstanceType(family+"."+smallest)) {
+			continue
+		}
+		candidates = append(candidates, t)
+	}
+	candidates = append(candidates, g.instanceTypes...)
+
+	var best types.InstanceType
+	bestFactor, bestWaste := 0.0, math.Inf(1)
+	for _, t := range candidates {
+		factor := Units(t)
+		waste := math.Ceil(units/factor-1e-9)*factor - units
+		if waste < bestWaste || (waste == bestWaste && factor > bestFactor) {
+			best, bestFactor, bestWaste = t, factor, waste
+		}
+	}
+	return best, bestFactor
+}
+
+func containsInstanceType(instanceTypes []types.InstanceType, t types.InstanceType) bool {
+	for _, it := range instanceTypes {
+		if it == t {
+			return true
+		}
+	}
+	return false
+}
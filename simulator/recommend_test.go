@@ -0,0 +1,122 @@
+package simurator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func testRecommendResult() SimulatorResult {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	launched := aws.Time(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	instance := func(id string, t types.InstanceType, platform string, az string) types.Instance {
+		return types.Instance{
+			InstanceId:      aws.String(id),
+			InstanceType:    t,
+			PlatformDetails: aws.String(platform),
+			State:           running,
+			LaunchTime:      launched,
+			Placement:       &types.Placement{AvailabilityZone: aws.String(az)},
+		}
+	}
+	young := instance("young", "m5.large", "Linux/UNIX", "ap-northeast-1a")
+	young.LaunchTime = aws.Time(time.Date(2022, 6, 25, 0, 0, 0, 0, time.UTC))
+	stopped := instance("stopped", "m5.large", "Linux/UNIX", "ap-northeast-1a")
+	stopped.State = &types.InstanceState{Name: types.InstanceStateNameStopped}
+	return SimulatorResult{
+		MatchInstanceResults: []types.Instance{
+			instance("covered", "m5.large", "Linux/UNIX", "ap-northeast-1a"),
+		},
+		PartialMatchInstanceResults: []PartialMatchInstanceResult{
+			{
+				Instance:     instance("partial", "m5.2xlarge", "Linux/UNIX", "ap-northeast-1c"),
+				Units:        16,
+				CoveredUnits: 8,
+			},
+		},
+		UnmatchInstanceResults: []types.Instance{
+			instance("m5-xlarge", "m5.xlarge", "Linux/UNIX", "ap-northeast-1a"),
+			instance("windows", "t3.medium", "Windows", "ap-northeast-1a"),
+			young,
+			stopped,
+		},
+	}
+}
+
+func TestSimulatorResult_Recommend(t *testing.T) {
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts RecommendOptions
+		want []Recommendation
+	}{
+		{
+			name: "size flexible RI per family",
+			opts: RecommendOptions{TargetCoverage: 100, MinAge: 30 * 24 * time.Hour, Now: now},
+			want: []Recommendation{
+				{InstanceType: "m5.2xlarge", ProductDescription: "Linux/UNIX", Tenancy: "default", InstanceCount: 1, Units: 16, UncoveredUnits: 16, Instances: []string{"partial", "m5-xlarge"}},
+				{InstanceType: "t3.medium", ProductDescription: "Windows", Tenancy: "default", InstanceCount: 1, Units: 2, UncoveredUnits: 2, Instances: []string{"windows"}},
+			},
+		},
+		{
+			name: "young instances are reserved without min age",
+			opts: RecommendOptions{TargetCoverage: 100, Now: now},
+			want: []Recommendation{
+				{InstanceType: "m5.large", ProductDescription: "Linux/UNIX", Tenancy: "default", InstanceCount: 5, Units: 20, UncoveredUnits: 20, Instances: []string{"partial", "m5-xlarge", "young"}},
+				{InstanceType: "t3.medium", ProductDescription: "Windows", Tenancy: "default", InstanceCount: 1, Units: 2, UncoveredUnits: 2, Instances: []string{"windows"}},
+			},
+		},
+		{
+			name: "target coverage",
+			// 34 running units, 12 covered: 5 more units reach 50%,
+			// with the same unused units as 2x m5.large but fewer RIs
+			opts: RecommendOptions{TargetCoverage: 50, MinAge: 30 * 24 * time.Hour, Now: now},
+			want: []Recommendation{
+				{InstanceType: "m5.xlarge", ProductDescription: "Linux/UNIX", Tenancy: "default", InstanceCount: 1, Units: 8, UncoveredUnits: 5, Instances: []string{"partial"}},
+			},
+		},
+		{
+			name: "target already reached",
+			opts: RecommendOptions{TargetCoverage: 30, Now: now},
+			want: nil,
+		},
+		{
+			name: "zonal",
+			opts: RecommendOptions{TargetCoverage: 100, MinAge: 30 * 24 * time.Hour, Now: now, Zonal: true},
+			want: []Recommendation{
+				{InstanceType: "m5.2xlarge", ProductDescription: "Linux/UNIX", Tenancy: "default", AvailabilityZone: "ap-northeast-1c", InstanceCount: 1, Units: 16, UncoveredUnits: 8, Instances: []string{"partial"}},
+				{InstanceType: "m5.xlarge", ProductDescription: "Linux/UNIX", Tenancy: "default", AvailabilityZone: "ap-northeast-1a", InstanceCount: 1, Units: 8, UncoveredUnits: 8, Instances: []string{"m5-xlarge"}},
+				{InstanceType: "t3.medium", ProductDescription: "Windows", Tenancy: "default", AvailabilityZone: "ap-northeast-1a", InstanceCount: 1, Units: 2, UncoveredUnits: 2, Instances: []string{"windows"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testRecommendResult().Recommend(tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SimulatorResult.Recommend() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulatorResult_Recommend_existingSizes(t *testing.T) {
+	// 12.8 units would fit 2x x1.xlarge, but x1 has no sizes below 16xlarge
+	result := SimulatorResult{
+		UnmatchInstanceResults: []types.Instance{
+			{
+				InstanceId:   aws.String("x1"),
+				InstanceType: "x1.16xlarge",
+				State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+			},
+		},
+	}
+	want := []Recommendation{
+		{InstanceType: "x1.16xlarge", ProductDescription: "Linux/UNIX", Tenancy: "default", InstanceCount: 1, Units: 128, UncoveredUnits: 12.8, Instances: []string{"x1"}},
+	}
+	if got := result.Recommend(RecommendOptions{TargetCoverage: 10}); !reflect.DeepEqual(got, want) {
+		t.Errorf("SimulatorResult.Recommend() = %+v, want %+v", got, want)
+	}
+}
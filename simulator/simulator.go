@@ -1,54 +1,263 @@
 package simurator
 
 import (
+	"math"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 type Simulator struct {
 	Instances         []types.Instance
 	ReservedInstances []types.ReservedInstances
+	// Accounts maps InstanceId and ReservedInstancesId to the owner account
+	// when instances of several accounts are pooled (consolidated billing).
+	Accounts map[string]string
 }
 
 type SimulatorResult struct {
 	MatchInstanceResults           []types.Instance
+	PartialMatchInstanceResults    []PartialMatchInstanceResult
 	UnmatchInstanceResults         []types.Instance
-	UnmatchReservedInstanceResults []types.ReservedInstances
+	UnmatchReservedInstanceResults []ReservedInstanceResult
+	// every reservation with its used/remaining units
+	ReservedInstanceResults []ReservedInstanceResult
+	// which reservation covers which instance
+	Allocations []Allocation
+}
+
+// PartialMatchInstanceResult is an instance only partly covered by RIs,
+// e.g. m5.2xlarge (16 units) covered by one m5.xlarge RI (8 units).
+type PartialMatchInstanceResult struct {
+	types.Instance
+	Units        float64
+	CoveredUnits float64
+}
+
+// ReservedInstanceResult is a reservation with its normalized units.
+type ReservedInstanceResult struct {
+	types.ReservedInstances
+	Units          float64
+	UsedUnits      float64
+	RemainingUnits float64
+}
+
+// UsedCount returns the number of used RIs, e.g. 0.5 for half an m5.xlarge.
+func (r ReservedInstanceResult) UsedCount() float64 {
+	return r.UsedUnits / Units(r.InstanceType)
+}
+
+// RemainingCount returns the number of remaining RIs.
+func (r ReservedInstanceResult) RemainingCount() float64 {
+	return r.RemainingUnits / Units(r.InstanceType)
+}
+
+// Allocation records the units of a reservation consumed by an instance.
+type Allocation struct {
+	ReservedInstancesId string
+	InstanceId          string
+	Units               float64
+}
+
+// AllocationsOf returns the allocations of the reservation.
+func (r SimulatorResult) AllocationsOf(reservedInstancesId string) []Allocation {
+	var allocations []Allocation
+	for _, a := range r.Allocations {
+		if a.ReservedInstancesId == reservedInstancesId {
+			allocations = append(allocations, a)
+		}
+	}
+	return allocations
 }
 
+// Simulate applies ReservedInstances to Instances in allocation order
+// (see order.go). Results are listed in the order of Instances and
+// ReservedInstances.
+// Neither Instances nor ReservedInstances are modified, so the same
+// Simulator can be simulated repeatedly.
 func (sim *Simulator) Simulate() (SimulatorResult, error) {
 	results := SimulatorResult{}
+	ledger := newLedger(sim.ReservedInstances)
+	ledger.accounts = sim.Accounts
 
-	for _, i := range sim.Instances {
-		if sim.is_match(i) {
-			results.MatchInstanceResults = append(results.MatchInstanceResults, i)
-		} else {
+	covered := make([]float64, len(sim.Instances))
+	// RIs are applied to instances of the owner account first,
+	// then shared with the other accounts
+	passes := []bool{false}
+	if sim.Accounts != nil {
+		passes = []bool{true, false}
+	}
+	for _, ownerOnly := range passes {
+		ledger.ownerOnly = ownerOnly
+		for _, n := range instanceOrder(sim.Instances) {
+			covered[n] = ledger.cover(sim.Instances[n], covered[n])
+		}
+	}
+
+	for n, i := range sim.Instances {
+		units := Units(i.InstanceType)
+		covered := covered[n]
+		switch {
+		case covered == 0:
 			results.UnmatchInstanceResults = append(results.UnmatchInstanceResults, i)
+		case covered < units:
+			results.PartialMatchInstanceResults = append(results.PartialMatchInstanceResults, PartialMatchInstanceResult{
+				Instance:     i,
+				Units:        units,
+				CoveredUnits: covered,
+			})
+		default:
+			results.MatchInstanceResults = append(results.MatchInstanceResults, i)
 		}
 	}
 
-	for _, ri := range sim.ReservedInstances {
-		if *ri.InstanceCount != 0 {
-			results.UnmatchReservedInstanceResults = append(results.UnmatchReservedInstanceResults, ri)
+	for n, ri := range sim.ReservedInstances {
+		r := ReservedInstanceResult{
+			ReservedInstances: ri,
+			Units:             riUnits(ri),
+			UsedUnits:         riUnits(ri) - ledger.remaining[n],
+			RemainingUnits:    ledger.remaining[n],
+		}
+		results.ReservedInstanceResults = append(results.ReservedInstanceResults, r)
+		if r.RemainingUnits != 0 {
+			results.UnmatchReservedInstanceResults = append(results.UnmatchReservedInstanceResults, r)
 		}
 	}
+	results.Allocations = ledger.allocations
 
 	return results, nil
 }
 
-func (sim *Simulator) is_match(i types.Instance) bool {
-	if i.State.Name != types.InstanceStateNameRunning {
-		return false
+// ledger keeps the remaining normalized units of each reservation
+// during a simulation.
+type ledger struct {
+	reservedInstances []types.ReservedInstances
+	// remaining units of reservedInstances (same index)
+	remaining []float64
+	// reservedInstanceOrder, and the order per instance type derived from it
+	order       []int
+	typeOrders  map[types.InstanceType][]int
+	allocations []Allocation
+	// owner account of instances and reservations
+	accounts map[string]string
+	// apply RIs only to instances of the owner account
+	ownerOnly bool
+}
+
+func newLedger(ris []types.ReservedInstances) *ledger {
+	l := &ledger{
+		reservedInstances: ris,
+		remaining:         make([]float64, len(ris)),
+		order:             reservedInstanceOrder(ris),
+		typeOrders:        map[types.InstanceType][]int{},
+	}
+	for n, ri := range ris {
+		l.remaining[n] = riUnits(ri)
 	}
-	for _, ri := range sim.ReservedInstances {
-		if *ri.InstanceCount == 0 {
+	return l
+}
+
+// cover consumes RI units for the not yet covered part of the instance
+// and returns the covered units.
+func (l *ledger) cover(i types.Instance, covered float64) float64 {
+	if i.State == nil || i.State.Name != types.InstanceStateNameRunning {
+		return covered
+	}
+	units := Units(i.InstanceType)
+	for _, n := range l.orderOf(i.InstanceType) {
+		ri := l.reservedInstances[n]
+		if covered == units {
+			break
+		}
+		if l.remaining[n] == 0 {
 			continue
 		}
-		if i.InstanceType == ri.InstanceType {
-			if string(i.Platform) == string(ri.ProductDescription) {
-				*ri.InstanceCount -= 1
-				return true
-			}
+		owner := l.accounts[aws.ToString(ri.ReservedInstancesId)] == l.accounts[aws.ToString(i.InstanceId)]
+		if l.ownerOnly && !owner {
+			continue
 		}
+		// AZ names are mapped to physical zones per account, so the AZ
+		// of a zonal RI is known only in the owner account
+		if is_zonal(ri) && !owner {
+			continue
+		}
+		if !is_applicable(ri, i) {
+			continue
+		}
+		use := math.Min(l.remaining[n], units-covered)
+		l.remaining[n] -= use
+		covered += use
+		l.allocations = append(l.allocations, Allocation{
+			ReservedInstancesId: aws.ToString(ri.ReservedInstancesId),
+			InstanceId:          aws.ToString(i.InstanceId),
+			Units:               use,
+		})
+	}
+	return covered
+}
+
+// orderOf returns the order in which reservations are applied to instances
+// of the type.
+func (l *ledger) orderOf(t types.InstanceType) []int {
+	order, ok := l.typeOrders[t]
+	if !ok {
+		order = instanceTypeOrder(l.reservedInstances, l.order, t)
+		l.typeOrders[t] = order
+	}
+	return order
+}
+
+func is_applicable(ri types.ReservedInstances, i types.Instance) bool {
+	if InstanceProduct(i) != ReservedInstanceProduct(ri) {
+		return false
+	}
+	// dedicated RIs apply only to dedicated instances and default RIs
+	// only to default instances (instances on Dedicated Hosts never match)
+	if InstanceTenancy(i) != ReservedInstanceTenancy(ri) {
+		return false
+	}
+	if is_zonal(ri) {
+		// zonal RIs apply only to the exact instance type in the same AZ
+		return i.InstanceType == ri.InstanceType &&
+			i.Placement != nil && i.Placement.AvailabilityZone != nil &&
+			ri.AvailabilityZone != nil &&
+			*i.Placement.AvailabilityZone == *ri.AvailabilityZone
+	}
+	if i.InstanceType == ri.InstanceType {
+		return true
+	}
+	if !is_size_flexible(ri) {
+		return false
+	}
+	if _, ok := NormalizationFactor(i.InstanceType); !ok {
+		return false
+	}
+	riFamily, _ := SplitInstanceType(ri.InstanceType)
+	family, _ := SplitInstanceType(i.InstanceType)
+	return riFamily == family
+}
+
+// is_zonal reports whether the RI is scoped to an Availability Zone.
+func is_zonal(ri types.ReservedInstances) bool {
+	return ri.Scope == types.ScopeAvailabilityZone
+}
+
+// is_size_flexible reports whether the RI applies across sizes in the family.
+// Only regional Linux/UNIX RIs of default tenancy are size flexible,
+// not e.g. RHEL, SUSE or dedicated RIs.
+func is_size_flexible(ri types.ReservedInstances) bool {
+	if is_zonal(ri) || ReservedInstanceTenancy(ri) != types.TenancyDefault {
+		return false
+	}
+	if _, ok := NormalizationFactor(ri.InstanceType); !ok {
+		return false
+	}
+	return ReservedInstanceProduct(ri) == ProductLinux
+}
+
+func riUnits(ri types.ReservedInstances) float64 {
+	if ri.InstanceCount == nil {
+		return 0
 	}
-	return false
+	return float64(*ri.InstanceCount) * Units(ri.InstanceType)
 }
@@ -3,12 +3,13 @@ package simurator
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
-func TestSimulator_is_match(t *testing.T) {
+func Test_ledger_cover(t *testing.T) {
 	type fields struct {
 		Instances         []types.Instance
 		ReservedInstances []types.ReservedInstances
@@ -20,7 +21,7 @@ func TestSimulator_is_match(t *testing.T) {
 		name   string
 		fields fields
 		args   args
-		want   bool
+		want   float64
 	}{
 		{
 			name:   "Stopped instance is NOT match",
@@ -30,7 +31,7 @@ func TestSimulator_is_match(t *testing.T) {
 					State: &types.InstanceState{Name: types.InstanceStateNameStopped},
 				},
 			},
-			want: false,
+			want: 0,
 		},
 		{
 			name: "Match",
@@ -46,7 +47,192 @@ func TestSimulator_is_match(t *testing.T) {
 					Platform:     types.PlatformValues("Linux/UNIX"),
 				},
 			},
-			want: true,
+			want: 2,
+		},
+		{
+			name: "Linux/UNIX (Amazon VPC) RI covers Linux/UNIX instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "t3.medium",
+				ProductDescription: types.RIProductDescription("Linux/UNIX (Amazon VPC)"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "t3.medium",
+				},
+			},
+			want: 2,
+		},
+		{
+			name: "Linux/UNIX RI does NOT cover RHEL instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "t3.medium",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:           &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType:    "t3.medium",
+					PlatformDetails: aws.String("Red Hat Enterprise Linux"),
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "RHEL RI covers RHEL instance by usage operation",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "t3.medium",
+				ProductDescription: types.RIProductDescription("Red Hat Enterprise Linux"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:          &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType:   "t3.medium",
+					UsageOperation: aws.String("RunInstances:0010"),
+				},
+			},
+			want: 2,
+		},
+		{
+			name: "RHEL RI is NOT size flexible",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.xlarge",
+				ProductDescription: types.RIProductDescription("Red Hat Enterprise Linux"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:           &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType:    "m5.large",
+					PlatformDetails: aws.String("Red Hat Enterprise Linux"),
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Windows RI does NOT cover Windows with SQL Server instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Windows (Amazon VPC)"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:           &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType:    "m5.large",
+					Platform:        types.PlatformValuesWindows,
+					PlatformDetails: aws.String("Windows with SQL Server Standard"),
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Windows with SQL Server RI covers Windows with SQL Server instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Windows with SQL Server Standard (Amazon VPC)"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:           &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType:    "m5.large",
+					Platform:        types.PlatformValuesWindows,
+					PlatformDetails: aws.String("Windows with SQL Server Standard"),
+				},
+			},
+			want: 4,
+		},
+		{
+			name: "Dedicated RI covers dedicated instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				InstanceTenancy:    types.Tenancy("dedicated"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+					Placement:    &types.Placement{Tenancy: types.Tenancy("dedicated")},
+				},
+			},
+			want: 4,
+		},
+		{
+			name: "Default RI does NOT cover dedicated instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				InstanceTenancy:    types.Tenancy("default"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+					Placement:    &types.Placement{Tenancy: types.Tenancy("dedicated")},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Dedicated RI does NOT cover default instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				InstanceTenancy:    types.Tenancy("dedicated"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Default RI does NOT cover instance on a Dedicated Host",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+					Placement:    &types.Placement{Tenancy: types.Tenancy("host")},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Dedicated RI is NOT size flexible",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.xlarge",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				InstanceTenancy:    types.Tenancy("dedicated"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+					Placement:    &types.Placement{Tenancy: types.Tenancy("dedicated")},
+				},
+			},
+			want: 0,
 		},
 		{
 			name: "Num of RI is ZERO",
@@ -62,17 +248,135 @@ func TestSimulator_is_match(t *testing.T) {
 					Platform:     types.PlatformValues("Linux/UNIX"),
 				},
 			},
-			want: false,
+			want: 0,
+		},
+		{
+			name: "Larger RI covers smaller instance in the same family",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.xlarge",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+				},
+			},
+			want: 4,
+		},
+		{
+			name: "Smaller RI partially covers larger instance",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.xlarge",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.2xlarge",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+				},
+			},
+			want: 8,
+		},
+		{
+			name: "Different family is NOT match",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.xlarge",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "c5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Zonal RI in the same AZ",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				Scope:              types.ScopeAvailabilityZone,
+				AvailabilityZone:   aws.String("ap-northeast-1a"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+					Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1a")},
+				},
+			},
+			want: 4,
+		},
+		{
+			name: "Zonal RI in another AZ is NOT match",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				Scope:              types.ScopeAvailabilityZone,
+				AvailabilityZone:   aws.String("ap-northeast-1a"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+					Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1c")},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Zonal RI is NOT size flexible",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.xlarge",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+				Scope:              types.ScopeAvailabilityZone,
+				AvailabilityZone:   aws.String("ap-northeast-1a"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValues("Linux/UNIX"),
+					Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1a")},
+				},
+			},
+			want: 0,
+		},
+		{
+			name: "Windows RI is NOT size flexible",
+			fields: fields{ReservedInstances: []types.ReservedInstances{{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.xlarge",
+				ProductDescription: types.RIProductDescription("Windows"),
+			}}},
+			args: args{
+				i: types.Instance{
+					State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+					InstanceType: "m5.large",
+					Platform:     types.PlatformValuesWindows,
+				},
+			},
+			want: 0,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sim := &Simulator{
-				Instances:         tt.fields.Instances,
-				ReservedInstances: tt.fields.ReservedInstances,
-			}
-			if got := sim.is_match(tt.args.i); got != tt.want {
-				t.Errorf("Simulator.is_match() = %v, want %v", got, tt.want)
+			l := newLedger(tt.fields.ReservedInstances)
+			if got := l.cover(tt.args.i, 0); got != tt.want {
+				t.Errorf("ledger.cover() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -142,13 +446,265 @@ func TestSimulator_Simulate(t *testing.T) {
 						Platform:     types.PlatformValuesWindows,
 					},
 				},
-				UnmatchReservedInstanceResults: []types.ReservedInstances{
+				UnmatchReservedInstanceResults: []ReservedInstanceResult{
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "c5.xlarge",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						},
+						Units:          8,
+						UsedUnits:      0,
+						RemainingUnits: 8,
+					},
+				},
+				ReservedInstanceResults: []ReservedInstanceResult{
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "t3.medium",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						},
+						Units:          2,
+						UsedUnits:      2,
+						RemainingUnits: 0,
+					},
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "c5.xlarge",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						},
+						Units:          8,
+						UsedUnits:      0,
+						RemainingUnits: 8,
+					},
+				},
+				Allocations: []Allocation{
+					{Units: 2},
+				},
+			},
+		},
+		{
+			name: "Size flexibility",
+			fields: fields{
+				Instances: []types.Instance{
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.large",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+					},
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.large",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+					},
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.2xlarge",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+					},
+				},
+				ReservedInstances: []types.ReservedInstances{
+					{
+						InstanceCount:      aws.Int32(2),
+						InstanceType:       "m5.xlarge",
+						ProductDescription: types.RIProductDescription("Linux/UNIX"),
+					},
 					{
 						InstanceCount:      aws.Int32(1),
-						InstanceType:       "c5.xlarge",
+						InstanceType:       "m5.4xlarge",
+						ProductDescription: types.RIProductDescription("Linux/UNIX"),
+					},
+				},
+			},
+			wantErr: false,
+			want: SimulatorResult{
+				MatchInstanceResults: []types.Instance{
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.large",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+					},
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.large",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+					},
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.2xlarge",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+					},
+				},
+				UnmatchReservedInstanceResults: []ReservedInstanceResult{
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "m5.4xlarge",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						},
+						Units:          32,
+						UsedUnits:      8,
+						RemainingUnits: 24,
+					},
+				},
+				ReservedInstanceResults: []ReservedInstanceResult{
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(2),
+							InstanceType:       "m5.xlarge",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						},
+						Units:          16,
+						UsedUnits:      16,
+						RemainingUnits: 0,
+					},
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "m5.4xlarge",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						},
+						Units:          32,
+						UsedUnits:      8,
+						RemainingUnits: 24,
+					},
+				},
+				Allocations: []Allocation{
+					{Units: 4},
+					{Units: 4},
+					{Units: 8},
+					{Units: 8},
+				},
+			},
+		},
+		{
+			name: "Zonal RI is applied first",
+			fields: fields{
+				Instances: []types.Instance{
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.large",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+						Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1a")},
+					},
+				},
+				ReservedInstances: []types.ReservedInstances{
+					{
+						InstanceCount:      aws.Int32(1),
+						InstanceType:       "m5.large",
 						ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						Scope:              types.ScopeRegional,
+					},
+					{
+						InstanceCount:      aws.Int32(1),
+						InstanceType:       "m5.large",
+						ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						Scope:              types.ScopeAvailabilityZone,
+						AvailabilityZone:   aws.String("ap-northeast-1a"),
+					},
+				},
+			},
+			wantErr: false,
+			want: SimulatorResult{
+				MatchInstanceResults: []types.Instance{
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.large",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+						Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1a")},
+					},
+				},
+				UnmatchReservedInstanceResults: []ReservedInstanceResult{
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "m5.large",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+							Scope:              types.ScopeRegional,
+						},
+						Units:          4,
+						UsedUnits:      0,
+						RemainingUnits: 4,
+					},
+				},
+				ReservedInstanceResults: []ReservedInstanceResult{
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "m5.large",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+							Scope:              types.ScopeRegional,
+						},
+						Units:          4,
+						UsedUnits:      0,
+						RemainingUnits: 4,
+					},
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "m5.large",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+							Scope:              types.ScopeAvailabilityZone,
+							AvailabilityZone:   aws.String("ap-northeast-1a"),
+						},
+						Units:          4,
+						UsedUnits:      4,
+						RemainingUnits: 0,
+					},
+				},
+				Allocations: []Allocation{
+					{Units: 4},
+				},
+			},
+		},
+		{
+			name: "Partial match",
+			fields: fields{
+				Instances: []types.Instance{
+					{
+						State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+						InstanceType: "m5.2xlarge",
+						Platform:     types.PlatformValues("Linux/UNIX"),
+					},
+				},
+				ReservedInstances: []types.ReservedInstances{
+					{
+						InstanceCount:      aws.Int32(1),
+						InstanceType:       "m5.xlarge",
+						ProductDescription: types.RIProductDescription("Linux/UNIX"),
+					},
+				},
+			},
+			wantErr: false,
+			want: SimulatorResult{
+				PartialMatchInstanceResults: []PartialMatchInstanceResult{
+					{
+						Instance: types.Instance{
+							State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+							InstanceType: "m5.2xlarge",
+							Platform:     types.PlatformValues("Linux/UNIX"),
+						},
+						Units:        16,
+						CoveredUnits: 8,
 					},
 				},
+				ReservedInstanceResults: []ReservedInstanceResult{
+					{
+						ReservedInstances: types.ReservedInstances{
+							InstanceCount:      aws.Int32(1),
+							InstanceType:       "m5.xlarge",
+							ProductDescription: types.RIProductDescription("Linux/UNIX"),
+						},
+						Units:          8,
+						UsedUnits:      8,
+						RemainingUnits: 0,
+					},
+				},
+				Allocations: []Allocation{
+					{Units: 8},
+				},
 			},
 		},
 	}
@@ -169,3 +725,337 @@ func TestSimulator_Simulate(t *testing.T) {
 		})
 	}
 }
+
+func TestSimulator_Simulate_isRepeatable(t *testing.T) {
+	sim := &Simulator{
+		Instances: []types.Instance{
+			{
+				State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+				InstanceType: "m5.large",
+				Platform:     types.PlatformValues("Linux/UNIX"),
+			},
+		},
+		ReservedInstances: []types.ReservedInstances{
+			{
+				InstanceCount:      aws.Int32(1),
+				InstanceType:       "m5.large",
+				ProductDescription: types.RIProductDescription("Linux/UNIX"),
+			},
+		},
+	}
+	first, _ := sim.Simulate()
+	second, _ := sim.Simulate()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Simulator.Simulate() = %v, want %v", second, first)
+	}
+	if got := *sim.ReservedInstances[0].InstanceCount; got != 1 {
+		t.Errorf("ReservedInstances[0].InstanceCount = %v, want %v", got, 1)
+	}
+}
+
+func TestReservedInstanceResult_Count(t *testing.T) {
+	r := ReservedInstanceResult{
+		ReservedInstances: types.ReservedInstances{
+			InstanceCount: aws.Int32(2),
+			InstanceType:  "m5.xlarge",
+		},
+		Units:          16,
+		UsedUnits:      12,
+		RemainingUnits: 4,
+	}
+	if got := r.UsedCount(); got != 1.5 {
+		t.Errorf("ReservedInstanceResult.UsedCount() = %v, want %v", got, 1.5)
+	}
+	if got := r.RemainingCount(); got != 0.5 {
+		t.Errorf("ReservedInstanceResult.RemainingCount() = %v, want %v", got, 0.5)
+	}
+}
+
+func TestSimulatorResult_AllocationsOf(t *testing.T) {
+	sim := &Simulator{
+		Instances: []types.Instance{
+			{
+				InstanceId:   aws.String("i-0aa"),
+				State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+				InstanceType: "m5.large",
+				Platform:     types.PlatformValues("Linux/UNIX"),
+			},
+			{
+				InstanceId:   aws.String("i-0bb"),
+				State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+				InstanceType: "m5.large",
+				Platform:     types.PlatformValues("Linux/UNIX"),
+			},
+			{
+				InstanceId:   aws.String("i-0cc"),
+				State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+				InstanceType: "c5.large",
+				Platform:     types.PlatformValues("Linux/UNIX"),
+			},
+		},
+		ReservedInstances: []types.ReservedInstances{
+			{
+				ReservedInstancesId: aws.String("abc-123"),
+				InstanceCount:       aws.Int32(1),
+				InstanceType:        "m5.xlarge",
+				ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+			},
+			{
+				ReservedInstancesId: aws.String("def-456"),
+				InstanceCount:       aws.Int32(1),
+				InstanceType:        "c5.large",
+				ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+			},
+		},
+	}
+	results, _ := sim.Simulate()
+	tests := []struct {
+		name                string
+		reservedInstancesId string
+		want                []Allocation
+	}{
+		{
+			reservedInstancesId: "abc-123",
+			want: []Allocation{
+				{ReservedInstancesId: "abc-123", InstanceId: "i-0aa", Units: 4},
+				{ReservedInstancesId: "abc-123", InstanceId: "i-0bb", Units: 4},
+			},
+		},
+		{
+			reservedInstancesId: "def-456",
+			want: []Allocation{
+				{ReservedInstancesId: "def-456", InstanceId: "i-0cc", Units: 4},
+			},
+		},
+		{
+			reservedInstancesId: "xyz-999",
+			want:                nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := results.AllocationsOf(tt.reservedInstancesId); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SimulatorResult.AllocationsOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_instanceOrder(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	tests := []struct {
+		name      string
+		instances []types.Instance
+		want      []int
+	}{
+		{
+			name: "Smaller size first",
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "m5.2xlarge"},
+				{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "m5.large"},
+				{InstanceId: aws.String("i-000000000003"), State: running, InstanceType: "m5.xlarge"},
+			},
+			want: []int{1, 2, 0},
+		},
+		{
+			name: "Older launch time first",
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "m5.large", LaunchTime: aws.Time(time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC))},
+				{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "m5.large", LaunchTime: aws.Time(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "Instance ID breaks ties",
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "m5.large"},
+				{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "m5.large"},
+			},
+			want: []int{1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := instanceOrder(tt.instances); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instanceOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_reservedInstanceOrder(t *testing.T) {
+	instance := types.Instance{
+		InstanceType: "m5.large",
+		Platform:     types.PlatformValues("Linux/UNIX"),
+	}
+	tests := []struct {
+		name string
+		ris  []types.ReservedInstances
+		want []int
+	}{
+		{
+			name: "Zonal first",
+			ris: []types.ReservedInstances{
+				{ReservedInstancesId: aws.String("ri-1"), InstanceType: "m5.large", Scope: types.ScopeRegional},
+				{ReservedInstancesId: aws.String("ri-2"), InstanceType: "m5.large", Scope: types.ScopeAvailabilityZone},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "Exact instance type first, then smaller size",
+			ris: []types.ReservedInstances{
+				{ReservedInstancesId: aws.String("ri-1"), InstanceType: "m5.2xlarge"},
+				{ReservedInstancesId: aws.String("ri-2"), InstanceType: "m5.xlarge"},
+				{ReservedInstancesId: aws.String("ri-3"), InstanceType: "m5.large"},
+			},
+			want: []int{2, 1, 0},
+		},
+		{
+			name: "Standard before convertible",
+			ris: []types.ReservedInstances{
+				{ReservedInstancesId: aws.String("ri-1"), InstanceType: "m5.large", OfferingClass: types.OfferingClassTypeConvertible},
+				{ReservedInstancesId: aws.String("ri-2"), InstanceType: "m5.large", OfferingClass: types.OfferingClassTypeStandard},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "ReservedInstancesId breaks ties",
+			ris: []types.ReservedInstances{
+				{ReservedInstancesId: aws.String("ri-2"), InstanceType: "m5.large"},
+				{ReservedInstancesId: aws.String("ri-1"), InstanceType: "m5.large"},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "Exact instance type first in each scope",
+			ris: []types.ReservedInstances{
+				{ReservedInstancesId: aws.String("ri-1"), InstanceType: "m5.medium"},
+				{ReservedInstancesId: aws.String("ri-2"), InstanceType: "m5.large"},
+				{ReservedInstancesId: aws.String("ri-3"), InstanceType: "m5.large", Scope: types.ScopeAvailabilityZone},
+			},
+			want: []int{2, 1, 0},
+		},
+		{
+			name: "Other families and zonal RIs of other types left out",
+			ris: []types.ReservedInstances{
+				{ReservedInstancesId: aws.String("ri-1"), InstanceType: "c5.large"},
+				{ReservedInstancesId: aws.String("ri-2"), InstanceType: "m5.xlarge", Scope: types.ScopeAvailabilityZone},
+				{ReservedInstancesId: aws.String("ri-3"), InstanceType: "m5.xlarge"},
+			},
+			want: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := instanceTypeOrder(tt.ris, reservedInstanceOrder(tt.ris), instance.InstanceType)
+			if got := order; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("instanceTypeOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulator_Simulate_isDeterministic(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	ris := []types.ReservedInstances{
+		{
+			ReservedInstancesId: aws.String("ri-2"),
+			InstanceCount:       aws.Int32(1),
+			InstanceType:        "m5.xlarge",
+			ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+		},
+		{
+			ReservedInstancesId: aws.String("ri-1"),
+			InstanceCount:       aws.Int32(1),
+			InstanceType:        "m5.large",
+			ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+		},
+	}
+	instances := []types.Instance{
+		{InstanceId: aws.String("i-000000000003"), State: running, InstanceType: "m5.2xlarge", Platform: types.PlatformValues("Linux/UNIX")},
+		{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "m5.large", Platform: types.PlatformValues("Linux/UNIX")},
+		{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "m5.large", Platform: types.PlatformValues("Linux/UNIX")},
+	}
+	want := []Allocation{
+		{ReservedInstancesId: "ri-1", InstanceId: "i-000000000001", Units: 4},
+		{ReservedInstancesId: "ri-2", InstanceId: "i-000000000002", Units: 4},
+		{ReservedInstancesId: "ri-2", InstanceId: "i-000000000003", Units: 4},
+	}
+	// the result must not depend on the order returned by DescribeInstances
+	for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 2, 0}} {
+		sim := &Simulator{ReservedInstances: ris}
+		for _, n := range order {
+			sim.Instances = append(sim.Instances, instances[n])
+		}
+		results, _ := sim.Simulate()
+		if !reflect.DeepEqual(results.Allocations, want) {
+			t.Errorf("Simulator.Simulate() Allocations = %v, want %v", results.Allocations, want)
+		}
+	}
+}
+
+func TestSimulator_Simulate_ownerAccountFirst(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	sim := &Simulator{
+		Instances: []types.Instance{
+			{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "m5.large", Platform: types.PlatformValues("Linux/UNIX")},
+			{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "m5.large", Platform: types.PlatformValues("Linux/UNIX")},
+			{InstanceId: aws.String("i-000000000003"), State: running, InstanceType: "m5.large", Platform: types.PlatformValues("Linux/UNIX")},
+		},
+		ReservedInstances: []types.ReservedInstances{
+			{
+				ReservedInstancesId: aws.String("ri-1"),
+				InstanceCount:       aws.Int32(2),
+				InstanceType:        "m5.large",
+				ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+			},
+		},
+		Accounts: map[string]string{
+			"i-000000000001": "111111111111",
+			"i-000000000002": "222222222222",
+			"i-000000000003": "333333333333",
+			"ri-1":           "222222222222",
+		},
+	}
+	want := []Allocation{
+		{ReservedInstancesId: "ri-1", InstanceId: "i-000000000002", Units: 4},
+		{ReservedInstancesId: "ri-1", InstanceId: "i-000000000001", Units: 4},
+	}
+	results, _ := sim.Simulate()
+	if !reflect.DeepEqual(results.Allocations, want) {
+		t.Errorf("Simulator.Simulate() Allocations = %v, want %v", results.Allocations, want)
+	}
+}
+
+func TestSimulator_Simulate_zonalOwnerAccountOnly(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	placement := &types.Placement{AvailabilityZone: aws.String("ap-northeast-1a")}
+	sim := &Simulator{
+		Instances: []types.Instance{
+			{InstanceId: aws.String("i-000000000001"), State: running, InstanceType: "m5.large", Placement: placement, Platform: types.PlatformValues("Linux/UNIX")},
+			{InstanceId: aws.String("i-000000000002"), State: running, InstanceType: "m5.large", Placement: placement, Platform: types.PlatformValues("Linux/UNIX")},
+		},
+		ReservedInstances: []types.ReservedInstances{
+			{
+				ReservedInstancesId: aws.String("ri-1"),
+				InstanceCount:       aws.Int32(2),
+				InstanceType:        "m5.large",
+				ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+				Scope:               types.ScopeAvailabilityZone,
+				AvailabilityZone:    aws.String("ap-northeast-1a"),
+			},
+		},
+		Accounts: map[string]string{
+			"i-000000000001": "111111111111",
+			"i-000000000002": "222222222222",
+			"ri-1":           "222222222222",
+		},
+	}
+	want := []Allocation{
+		{ReservedInstancesId: "ri-1", InstanceId: "i-000000000002", Units: 4},
+	}
+	results, _ := sim.Simulate()
+	if !reflect.DeepEqual(results.Allocations, want) {
+		t.Errorf("Simulator.Simulate() Allocations = %v, want %v", results.Allocations, want)
+	}
+}
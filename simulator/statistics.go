@@ -0,0 +1,89 @@
+package simurator
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// Statistics summarizes RI coverage and utilization in normalized units.
+type Statistics struct {
+	// units of running instances
+	RunningUnits float64
+	// units of running instances covered by RIs
+	CoveredUnits float64
+	// units of purchased RIs
+	PurchasedUnits float64
+	// units of RIs applied to instances
+	UsedUnits float64
+}
+
+// Coverage returns the percentage of running units covered by RIs.
+func (s Statistics) Coverage() float64 {
+	if s.RunningUnits == 0 {
+		return 0
+	}
+	return s.CoveredUnits / s.RunningUnits * 100
+}
+
+// Utilization returns the percentage of purchased RI units in use.
+func (s Statistics) Utilization() float64 {
+	if s.PurchasedUnits == 0 {
+		return 0
+	}
+	return s.UsedUnits / s.PurchasedUnits * 100
+}
+
+// Add returns the sum of the statistics, e.g. of several regions.
+func (s Statistics) Add(o Statistics) Statistics {
+	return Statistics{
+		RunningUnits:   s.RunningUnits + o.RunningUnits,
+		CoveredUnits:   s.CoveredUnits + o.CoveredUnits,
+		PurchasedUnits: s.PurchasedUnits + o.PurchasedUnits,
+		UsedUnits:      s.UsedUnits + o.UsedUnits,
+	}
+}
+
+// Statistics returns the coverage and utilization of all instances and RIs.
+func (r SimulatorResult) Statistics() Statistics {
+	return r.statisticsBy(func(types.InstanceType, string) string { return "" })[""]
+}
+
+// StatisticsByFamily returns the statistics per instance family, e.g. "m5".
+func (r SimulatorResult) StatisticsByFamily() map[string]Statistics {
+	return r.statisticsBy(func(t types.InstanceType, _ string) string {
+		family, _ := SplitInstanceType(t)
+		return family
+	})
+}
+
+// StatisticsByPlatform returns the statistics per platform, e.g. "Linux/UNIX".
+// Instances are grouped by InstanceProduct and RIs by ReservedInstanceProduct.
+func (r SimulatorResult) StatisticsByPlatform() map[string]Statistics {
+	return r.statisticsBy(func(_ types.InstanceType, platform string) string {
+		return platform
+	})
+}
+
+func (r SimulatorResult) statisticsBy(key func(t types.InstanceType, product string) string) map[string]Statistics {
+	stats := map[string]Statistics{}
+	add := func(k string, s Statistics) {
+		stats[k] = stats[k].Add(s)
+	}
+	for _, i := range r.MatchInstanceResults {
+		units := Units(i.InstanceType)
+		add(key(i.InstanceType, InstanceProduct(i)), Statistics{RunningUnits: units, CoveredUnits: units})
+	}
+	for _, i := range r.PartialMatchInstanceResults {
+		add(key(i.InstanceType, InstanceProduct(i.Instance)), Statistics{RunningUnits: i.Units, CoveredUnits: i.CoveredUnits})
+	}
+	for _, i := range r.UnmatchInstanceResults {
+		// stopped instances are not charged, so they are not counted
+		if i.State == nil || i.State.Name != types.InstanceStateNameRunning {
+			continue
+		}
+		add(key(i.InstanceType, InstanceProduct(i)), Statistics{RunningUnits: Units(i.InstanceType)})
+	}
+	for _, ri := range r.ReservedInstanceResults {
+		add(key(ri.InstanceType, ReservedInstanceProduct(ri.ReservedInstances)), Statistics{PurchasedUnits: ri.Units, UsedUnits: ri.UsedUnits})
+	}
+	return stats
+}
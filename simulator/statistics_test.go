@@ -0,0 +1,82 @@
+package simurator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func testStatisticsResult() SimulatorResult {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	return SimulatorResult{
+		MatchInstanceResults: []types.Instance{
+			{InstanceType: "m5.large", Platform: "Linux/UNIX", State: running},
+		},
+		PartialMatchInstanceResults: []PartialMatchInstanceResult{
+			{
+				Instance:     types.Instance{InstanceType: "m5.2xlarge", Platform: "Linux/UNIX", State: running},
+				Units:        16,
+				CoveredUnits: 12,
+			},
+		},
+		UnmatchInstanceResults: []types.Instance{
+			{InstanceId: aws.String("running"), InstanceType: "t3.medium", Platform: "Windows", State: running},
+			// stopped instances are not counted
+			{InstanceId: aws.String("stopped"), InstanceType: "c5.xlarge", Platform: "Linux/UNIX", State: &types.InstanceState{Name: types.InstanceStateNameStopped}},
+		},
+		ReservedInstanceResults: []ReservedInstanceResult{
+			{
+				ReservedInstances: types.ReservedInstances{InstanceType: "m5.xlarge", ProductDescription: "Linux/UNIX"},
+				Units:             16,
+				UsedUnits:         16,
+			},
+			{
+				ReservedInstances: types.ReservedInstances{InstanceType: "c5.large", ProductDescription: "Linux/UNIX"},
+				Units:             4,
+				RemainingUnits:    4,
+			},
+		},
+	}
+}
+
+func TestSimulatorResult_Statistics(t *testing.T) {
+	got := testStatisticsResult().Statistics()
+	want := Statistics{RunningUnits: 22, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SimulatorResult.Statistics() = %v, want %v", got, want)
+	}
+	if c := got.Coverage(); c < 72.72 || c > 72.73 {
+		t.Errorf("Statistics.Coverage() = %v, want %v", c, 72.72)
+	}
+	if u := got.Utilization(); u != 80 {
+		t.Errorf("Statistics.Utilization() = %v, want %v", u, 80)
+	}
+	if c, u := (Statistics{}).Coverage(), (Statistics{}).Utilization(); c != 0 || u != 0 {
+		t.Errorf("Statistics{}.Coverage(), Utilization() = %v, %v, want 0, 0", c, u)
+	}
+}
+
+func TestSimulatorResult_StatisticsByFamily(t *testing.T) {
+	got := testStatisticsResult().StatisticsByFamily()
+	want := map[string]Statistics{
+		"m5": {RunningUnits: 20, CoveredUnits: 16, PurchasedUnits: 16, UsedUnits: 16},
+		"t3": {RunningUnits: 2},
+		"c5": {PurchasedUnits: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SimulatorResult.StatisticsByFamily() = %v, want %v", got, want)
+	}
+}
+
+func TestSimulatorResult_StatisticsByPlatform(t *testing.T) {
+	got := testStatisticsResult().StatisticsByPlatform()
+	want := map[string]Statistics{
+		"Linux/UNIX": {RunningUnits: 20, CoveredUnits: 16, PurchasedUnits: 20, UsedUnits: 16},
+		"Windows":    {RunningUnits: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SimulatorResult.StatisticsByPlatform() = %v, want %v", got, want)
+	}
+}
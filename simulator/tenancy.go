@@ -0,0 +1,21 @@
+package simurator
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// InstanceTenancy returns the tenancy of the instance, "default" if unset.
+func InstanceTenancy(i types.Instance) types.Tenancy {
+	if i.Placement == nil || i.Placement.Tenancy == "" {
+		return types.TenancyDefault
+	}
+	return i.Placement.Tenancy
+}
+
+// ReservedInstanceTenancy returns the tenancy of the RI, "default" if unset.
+func ReservedInstanceTenancy(ri types.ReservedInstances) types.Tenancy {
+	if ri.InstanceTenancy == "" {
+		return types.TenancyDefault
+	}
+	return ri.InstanceTenancy
+}
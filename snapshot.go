@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// SnapshotVersion is incremented when the Snapshot format changes
+const SnapshotVersion = 1
+
+// Snapshot is a bundle of instances and RIs fetched at a time,
+// for reproducible simulations, diffs and bug reports.
+// Reservations are stored as DescribeInstances returns them, and
+// normalized when the snapshot is simulated, as -instances-file is.
+type Snapshot struct {
+	Version           int                       `json:"version"`
+	Account           string                    `json:"account"`
+	Region            string                    `json:"region"`
+	FetchedAt         time.Time                 `json:"fetched_at"`
+	Reservations      []types.Reservation       `json:"reservations"`
+	ReservedInstances []types.ReservedInstances `json:"reserved_instances"`
+}
+
+func takeSnapshot(client Ec2Client, account, region string, now time.Time) (Snapshot, error) {
+	reservations, err := getReservations(client)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	ri_instances, err := getReservedInstances(client)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{
+		Version:           SnapshotVersion,
+		Account:           account,
+		Region:            region,
+		FetchedAt:         now.UTC(),
+		Reservations:      reservations,
+		ReservedInstances: ri_instances,
+	}, nil
+}
+
+func readSnapshot(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	if snapshot.Version != SnapshotVersion {
+		return Snapshot{}, fmt.Errorf("unsupported snapshot version: %d", snapshot.Version)
+	}
+	return snapshot, nil
+}
+
+func loadSnapshot(path string) (Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer f.Close()
+	return readSnapshot(f)
+}
+
+// simulateSnapshot simulates instances and RIs of a snapshot file
+func simulateSnapshot(path string) RegionResult {
+	snapshot, err := loadSnapshot(path)
+	if err != nil {
+		return RegionResult{Err: err}
+	}
+	sim := &simurator.Simulator{
+		Instances:         toInstances(snapshot.Reservations),
+		ReservedInstances: snapshot.ReservedInstances,
+	}
+	result := RegionResult{Region: snapshot.Region, Simulator: sim}
+	result.Results, result.Err = sim.Simulate()
+	return result
+}
+
+// runSnapshot is the "snapshot" subcommand
+func (cli *CLI) runSnapshot(args []string) int {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(cli.errStream)
+	var opts AWSOptions
+	opts.register(flags)
+	output := flags.String("o", "", "write the snapshot to `file` instead of stdout")
+	if code, ok := parseFlags(flags, args[1:]); !ok {
+		return code
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+
+	snapshot, err := takeSnapshot(ec2.NewFromConfig(cfg), aws.ToString(identity.Account), cfg.Region, time.Now())
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+
+	write := func(w io.Writer) error { return writeJSON(w, snapshot) }
+	if *output != "" {
+		// writeFile reports a failed Close, e.g. a truncated bundle
+		err = writeFile(*output, write)
+	} else {
+		err = write(cli.outStream)
+	}
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	return ExitCodeOK
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func Test_takeSnapshot(t *testing.T) {
+	client := MockEc2Client{
+		instances: []types.Instance{
+			{
+				InstanceId:   aws.String("i-000000000001"),
+				InstanceType: "t3.medium",
+				LaunchTime:   aws.Time(time.Date(2022, 1, 10, 1, 0, 0, 0, time.UTC)),
+				State:        &types.InstanceState{Code: aws.Int32(16), Name: types.InstanceStateNameRunning},
+			},
+			{
+				InstanceId:      aws.String("i-000000000002"),
+				InstanceType:    "t3.medium",
+				Platform:        types.PlatformValuesWindows,
+				PlatformDetails: aws.String("Windows with SQL Server Standard"),
+				State:           &types.InstanceState{Code: aws.Int32(16), Name: types.InstanceStateNameRunning},
+			},
+		},
+		reservedInstances: []types.ReservedInstances{
+			{
+				ReservedInstancesId: aws.String("ri-1"),
+				InstanceCount:       aws.Int32(1),
+				InstanceType:        "t3.medium",
+				ProductDescription:  types.RIProductDescription("Linux/UNIX"),
+				End:                 aws.Time(time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)),
+			},
+		},
+	}
+	now := time.Date(2022, 6, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+
+	got, err := takeSnapshot(client, "123456789012", "ap-northeast-1", now)
+	if err != nil {
+		t.Fatalf("takeSnapshot() error = %v", err)
+	}
+	if got.Version != SnapshotVersion || got.Account != "123456789012" || got.Region != "ap-northeast-1" {
+		t.Errorf("takeSnapshot() = %v %v %v", got.Version, got.Account, got.Region)
+	}
+	if !got.FetchedAt.Equal(now) || got.FetchedAt.Location() != time.UTC {
+		t.Errorf("takeSnapshot() FetchedAt = %v, want %v in UTC", got.FetchedAt, now)
+	}
+	// DescribeInstances data is stored as is, not normalized
+	if len(got.Reservations) != 1 || !reflect.DeepEqual(got.Reservations[0].Instances, client.instances) {
+		t.Errorf("takeSnapshot() Reservations = %v, want %v", got.Reservations, client.instances)
+	}
+
+	// round trip
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, got); err != nil {
+		t.Fatalf("writeJSON() error = %v", err)
+	}
+	read, err := readSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("readSnapshot() error = %v", err)
+	}
+	if !reflect.DeepEqual(read, got) {
+		t.Errorf("readSnapshot() = %v, want %v", read, got)
+	}
+}
+
+func Test_readSnapshot_version(t *testing.T) {
+	if _, err := readSnapshot(strings.NewReader(`{"version": 999}`)); err == nil {
+		t.Errorf("readSnapshot() error = nil, want error")
+	}
+}
+
+func Test_simulateSnapshot(t *testing.T) {
+	f, err := os.Open("testdata/describe-instances.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output ec2.DescribeInstancesOutput
+	err = json.NewDecoder(f).Decode(&output)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ri_instances, _ := loadReservedInstances("testdata/describe-reserved-instances.json")
+	snapshot := Snapshot{
+		Version:           SnapshotVersion,
+		Account:           "123456789012",
+		Region:            "ap-northeast-1",
+		Reservations:      output.Reservations,
+		ReservedInstances: ri_instances,
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	f, err = os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJSON(f, snapshot)
+	f.Close()
+
+	got := simulateSnapshot(path)
+	want := simulateFiles("testdata/describe-instances.json", "testdata/describe-reserved-instances.json")
+	if got.Err != nil {
+		t.Fatalf("simulateSnapshot() error = %v", got.Err)
+	}
+	if got.Region != "ap-northeast-1" {
+		t.Errorf("simulateSnapshot() Region = %v, want %v", got.Region, "ap-northeast-1")
+	}
+	if !reflect.DeepEqual(got.Results.Allocations, want.Results.Allocations) {
+		t.Errorf("simulateSnapshot() Allocations = %v, want %v", got.Results.Allocations, want.Results.Allocations)
+	}
+	// instances are normalized on load as -instances-file does
+	platforms := func(instances []types.Instance) []types.PlatformValues {
+		var platforms []types.PlatformValues
+		for _, i := range instances {
+			platforms = append(platforms, i.Platform)
+		}
+		return platforms
+	}
+	if got, want := platforms(got.Results.UnmatchInstanceResults), platforms(want.Results.UnmatchInstanceResults); !reflect.DeepEqual(got, want) {
+		t.Errorf("simulateSnapshot() platforms = %v, want %v", got, want)
+	}
+}
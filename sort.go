@@ -5,35 +5,43 @@ package main
 // https://pkg.go.dev/sort#example-package-SortMultiKeys
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
 )
 
-type lessFunc func(p1, p2 types.Instance) bool
+// lessFunc reports whether p1 sorts before p2
+type lessFunc[T any] func(p1, p2 T) bool
 
-func OrderBy(lessFunc ...lessFunc) *MultiSorter {
-	return &MultiSorter{
+func OrderBy[T any](lessFunc ...lessFunc[T]) *MultiSorter[T] {
+	return &MultiSorter[T]{
 		less: lessFunc,
 	}
 }
 
-type MultiSorter struct {
-	instances []types.Instance
-	less      []lessFunc
+// MultiSorter sorts rows of a report (instances, RIs, summaries, ...)
+// by several keys.
+type MultiSorter[T any] struct {
+	rows []T
+	less []lessFunc[T]
 }
 
-func (ms *MultiSorter) Sort(instances []types.Instance) {
-	ms.instances = instances
-	sort.Sort(ms)
+// Sort sorts the rows stably, so rows equal in every key keep their order.
+func (ms *MultiSorter[T]) Sort(rows []T) {
+	ms.rows = rows
+	sort.Stable(ms)
 }
 
-func (ms *MultiSorter) Len() int { return len(ms.instances) }
-func (ms *MultiSorter) Swap(i, j int) {
-	ms.instances[i], ms.instances[j] = ms.instances[j], ms.instances[i]
+func (ms *MultiSorter[T]) Len() int { return len(ms.rows) }
+func (ms *MultiSorter[T]) Swap(i, j int) {
+	ms.rows[i], ms.rows[j] = ms.rows[j], ms.rows[i]
 }
-func (ms *MultiSorter) Less(i, j int) bool {
-	p, q := ms.instances[i], ms.instances[j]
+func (ms *MultiSorter[T]) Less(i, j int) bool {
+	p, q := ms.rows[i], ms.rows[j]
 	var k int
 	for k = 0; k < len(ms.less)-1; k++ {
 		less := ms.less[k]
@@ -49,3 +57,167 @@ func (ms *MultiSorter) Less(i, j int) bool {
 	// the final comparison reports.
 	return ms.less[k](p, q)
 }
+
+// descending reverses the order of less
+func descending[T any](less lessFunc[T]) lessFunc[T] {
+	return func(p1, p2 T) bool {
+		return less(p2, p1)
+	}
+}
+
+// DefaultSort is the order of instances and RIs in reports
+const DefaultSort = "state,platform,type,name"
+
+// sortKey compares instances and RIs by the same attribute
+type sortKey struct {
+	instance         lessFunc[types.Instance]
+	reservedInstance lessFunc[simurator.ReservedInstanceResult]
+}
+
+// sortKeys are the keys accepted by -sort, besides tag:<Key>
+var sortKeys = map[string]sortKey{
+	"state": {
+		instance: func(p1, p2 types.Instance) bool {
+			return stateCode(p1) < stateCode(p2)
+		},
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return r1.State < r2.State
+		},
+	},
+	"platform": {
+		instance: func(p1, p2 types.Instance) bool {
+			return p1.Platform < p2.Platform
+		},
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return r1.ProductDescription < r2.ProductDescription
+		},
+	},
+	"type": {
+		instance: func(p1, p2 types.Instance) bool {
+			return p1.InstanceType < p2.InstanceType
+		},
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return r1.InstanceType < r2.InstanceType
+		},
+	},
+	"tenancy": {
+		instance: func(p1, p2 types.Instance) bool {
+			return simurator.InstanceTenancy(p1) < simurator.InstanceTenancy(p2)
+		},
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return simurator.ReservedInstanceTenancy(r1.ReservedInstances) < simurator.ReservedInstanceTenancy(r2.ReservedInstances)
+		},
+	},
+	"name": tagSortKey("Name"),
+	"id": {
+		instance: func(p1, p2 types.Instance) bool {
+			return aws.ToString(p1.InstanceId) < aws.ToString(p2.InstanceId)
+		},
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return aws.ToString(r1.ReservedInstancesId) < aws.ToString(r2.ReservedInstancesId)
+		},
+	},
+	"az": {
+		instance: func(p1, p2 types.Instance) bool {
+			return availabilityZone(p1) < availabilityZone(p2)
+		},
+		// regional RIs have no Availability Zone
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return aws.ToString(r1.AvailabilityZone) < aws.ToString(r2.AvailabilityZone)
+		},
+	},
+	// the start of the term for RIs
+	"launch-time": {
+		instance: func(p1, p2 types.Instance) bool {
+			return aws.ToTime(p1.LaunchTime).Before(aws.ToTime(p2.LaunchTime))
+		},
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return aws.ToTime(r1.Start).Before(aws.ToTime(r2.Start))
+		},
+	},
+}
+
+func tagSortKey(key string) sortKey {
+	return sortKey{
+		instance: func(p1, p2 types.Instance) bool {
+			return tagValue(p1.Tags, key) < tagValue(p2.Tags, key)
+		},
+		reservedInstance: func(r1, r2 simurator.ReservedInstanceResult) bool {
+			return tagValue(r1.Tags, key) < tagValue(r2.Tags, key)
+		},
+	}
+}
+
+func tagValue(tags []types.Tag, key string) string {
+	for _, t := range tags {
+		if aws.ToString(t.Key) == key {
+			return aws.ToString(t.Value)
+		}
+	}
+	return ""
+}
+
+func stateCode(i types.Instance) int32 {
+	if i.State == nil {
+		return 0
+	}
+	return aws.ToInt32(i.State.Code)
+}
+
+func availabilityZone(i types.Instance) string {
+	if i.Placement == nil {
+		return ""
+	}
+	return aws.ToString(i.Placement.AvailabilityZone)
+}
+
+// ReportSorter sorts the instance and RI sections of a report
+type ReportSorter struct {
+	instances         *MultiSorter[types.Instance]
+	partialInstances  *MultiSorter[simurator.PartialMatchInstanceResult]
+	reservedInstances *MultiSorter[simurator.ReservedInstanceResult]
+}
+
+// parseSort parses comma-separated sort keys, e.g. "type,-launch-time,tag:Env".
+// A key prefixed with "-" sorts in descending order.
+func parseSort(s string) (*ReportSorter, error) {
+	var less []lessFunc[types.Instance]
+	var partialLess []lessFunc[simurator.PartialMatchInstanceResult]
+	var riLess []lessFunc[simurator.ReservedInstanceResult]
+	for _, key := range parseList(s) {
+		name := strings.TrimPrefix(key, "-")
+		k, ok := sortKeys[name]
+		if strings.HasPrefix(name, "tag:") && len(name) > len("tag:") {
+			k, ok = tagSortKey(strings.TrimPrefix(name, "tag:")), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid -sort key: %s", key)
+		}
+		if strings.HasPrefix(key, "-") {
+			k.instance = descending(k.instance)
+			k.reservedInstance = descending(k.reservedInstance)
+		}
+		instance := k.instance
+		less = append(less, instance)
+		partialLess = append(partialLess, func(p1, p2 simurator.PartialMatchInstanceResult) bool {
+			return instance(p1.Instance, p2.Instance)
+		})
+		riLess = append(riLess, k.reservedInstance)
+	}
+	if len(less) == 0 {
+		return nil, fmt.Errorf("invalid -sort: %q", s)
+	}
+	return &ReportSorter{
+		instances:         OrderBy(less...),
+		partialInstances:  OrderBy(partialLess...),
+		reservedInstances: OrderBy(riLess...),
+	}, nil
+}
+
+// SortResults sorts the instance and RI sections of the results.
+func (s *ReportSorter) SortResults(results simurator.SimulatorResult) {
+	s.instances.Sort(results.MatchInstanceResults)
+	s.partialInstances.Sort(results.PartialMatchInstanceResults)
+	s.instances.Sort(results.UnmatchInstanceResults)
+	s.reservedInstances.Sort(results.UnmatchReservedInstanceResults)
+}
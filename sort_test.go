@@ -2,15 +2,17 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
 )
 
 func TestMultiSorter_Len(t *testing.T) {
 	type fields struct {
-		instances []types.Instance
-		less      []lessFunc
+		rows []types.Instance
+		less []lessFunc[types.Instance]
 	}
 	tests := []struct {
 		name   string
@@ -19,7 +21,7 @@ func TestMultiSorter_Len(t *testing.T) {
 	}{
 		{
 			fields: fields{
-				instances: []types.Instance{
+				rows: []types.Instance{
 					{
 						InstanceId: aws.String("i-000000000001"),
 					},
@@ -33,9 +35,9 @@ func TestMultiSorter_Len(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ms := &MultiSorter{
-				instances: tt.fields.instances,
-				less:      tt.fields.less,
+			ms := &MultiSorter[types.Instance]{
+				rows: tt.fields.rows,
+				less: tt.fields.less,
 			}
 			if got := ms.Len(); got != tt.want {
 				t.Errorf("MultiSorter.Len() = %v, want %v", got, tt.want)
@@ -46,8 +48,8 @@ func TestMultiSorter_Len(t *testing.T) {
 
 func TestMultiSorter_Swap(t *testing.T) {
 	type fields struct {
-		instances []types.Instance
-		less      []lessFunc
+		rows []types.Instance
+		less []lessFunc[types.Instance]
 	}
 	type args struct {
 		i int
@@ -61,7 +63,7 @@ func TestMultiSorter_Swap(t *testing.T) {
 	}{
 		{
 			fields: fields{
-				instances: []types.Instance{
+				rows: []types.Instance{
 					{
 						InstanceId: aws.String("i-000000000001"),
 					},
@@ -79,15 +81,15 @@ func TestMultiSorter_Swap(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ms := &MultiSorter{
-				instances: tt.fields.instances,
-				less:      tt.fields.less,
+			ms := &MultiSorter[types.Instance]{
+				rows: tt.fields.rows,
+				less: tt.fields.less,
 			}
 			ms.Swap(tt.args.i, tt.args.j)
-			if got := ms.instances[0].InstanceId; *got != tt.want[0] {
+			if got := ms.rows[0].InstanceId; *got != tt.want[0] {
 				t.Errorf("MultiSorter.Swap() instances[0].InstanceId = %v, want %v", *got, tt.want[0])
 			}
-			if got := ms.instances[1].InstanceId; *got != tt.want[1] {
+			if got := ms.rows[1].InstanceId; *got != tt.want[1] {
 				t.Errorf("MultiSorter.Swap() instances[1].InstanceId = %v, want %v", *got, tt.want[1])
 			}
 		})
@@ -96,7 +98,7 @@ func TestMultiSorter_Swap(t *testing.T) {
 
 func TestOrderBy(t *testing.T) {
 	type args struct {
-		lessFunc  []lessFunc
+		lessFunc  []lessFunc[types.Instance]
 		instances []types.Instance
 	}
 	tests := []struct {
@@ -106,7 +108,7 @@ func TestOrderBy(t *testing.T) {
 	}{
 		{
 			args: args{
-				lessFunc: []lessFunc{
+				lessFunc: []lessFunc[types.Instance]{
 					func(p1, p2 types.Instance) bool {
 						return *p1.InstanceId < *p2.InstanceId
 					},
@@ -124,7 +126,7 @@ func TestOrderBy(t *testing.T) {
 		},
 		{
 			args: args{
-				lessFunc: []lessFunc{
+				lessFunc: []lessFunc[types.Instance]{
 					func(p1, p2 types.Instance) bool {
 						return *p1.InstanceId > *p2.InstanceId
 					},
@@ -142,7 +144,7 @@ func TestOrderBy(t *testing.T) {
 		},
 		{
 			args: args{
-				lessFunc: []lessFunc{
+				lessFunc: []lessFunc[types.Instance]{
 					func(p1, p2 types.Instance) bool {
 						return string(p1.InstanceType) < string(p2.InstanceType)
 					},
@@ -165,7 +167,7 @@ func TestOrderBy(t *testing.T) {
 		},
 		{
 			args: args{
-				lessFunc: []lessFunc{
+				lessFunc: []lessFunc[types.Instance]{
 					func(p1, p2 types.Instance) bool {
 						return string(p1.InstanceType) < string(p2.InstanceType)
 					},
@@ -203,3 +205,135 @@ func TestOrderBy(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseSort(t *testing.T) {
+	instances := func() []types.Instance {
+		return []types.Instance{
+			{
+				InstanceId:   aws.String("i-000000000001"),
+				InstanceType: "m5.large",
+				LaunchTime:   aws.Time(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)),
+				Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1c")},
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String("web02")},
+					{Key: aws.String("Env"), Value: aws.String("stg")},
+				},
+			},
+			{
+				InstanceId:   aws.String("i-000000000002"),
+				InstanceType: "c5.large",
+				LaunchTime:   aws.Time(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)),
+				Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1a")},
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String("web01")},
+					{Key: aws.String("Env"), Value: aws.String("prd")},
+				},
+			},
+			{
+				InstanceId:   aws.String("i-000000000003"),
+				InstanceType: "c5.large",
+				LaunchTime:   aws.Time(time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)),
+				Placement:    &types.Placement{AvailabilityZone: aws.String("ap-northeast-1d")},
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String("web03")},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{name: "name", s: "name", want: []string{"i-000000000002", "i-000000000001", "i-000000000003"}},
+		{name: "type,name", s: "type,name", want: []string{"i-000000000002", "i-000000000003", "i-000000000001"}},
+		{name: "type,-name", s: "type,-name", want: []string{"i-000000000003", "i-000000000002", "i-000000000001"}},
+		{name: "-id", s: "-id", want: []string{"i-000000000003", "i-000000000002", "i-000000000001"}},
+		{name: "az", s: "az", want: []string{"i-000000000002", "i-000000000001", "i-000000000003"}},
+		{name: "launch-time", s: "launch-time", want: []string{"i-000000000002", "i-000000000003", "i-000000000001"}},
+		{name: "tag:Env", s: "tag:Env,id", want: []string{"i-000000000003", "i-000000000002", "i-000000000001"}},
+		{name: "unknown key", s: "type,size", wantErr: true},
+		{name: "empty tag", s: "tag:", wantErr: true},
+		{name: "empty", s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorter, err := parseSort(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSort() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			got := instances()
+			sorter.instances.Sort(got)
+			for n, id := range tt.want {
+				if *got[n].InstanceId != id {
+					t.Errorf("parseSort() sorted[%d] = %v, want %v", n, *got[n].InstanceId, id)
+				}
+			}
+		})
+	}
+}
+
+func TestReportSorter_SortResults(t *testing.T) {
+	ri := func(id string, instanceType types.InstanceType, start time.Time) simurator.ReservedInstanceResult {
+		return simurator.ReservedInstanceResult{
+			ReservedInstances: types.ReservedInstances{
+				ReservedInstancesId: aws.String(id),
+				InstanceType:        instanceType,
+				Start:               aws.Time(start),
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{name: "type", s: "type", want: []string{"ri-2", "ri-3", "ri-1"}},
+		{name: "-launch-time", s: "-launch-time", want: []string{"ri-1", "ri-3", "ri-2"}},
+		{name: "type,-id", s: "type,-id", want: []string{"ri-3", "ri-2", "ri-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := simurator.SimulatorResult{
+				UnmatchReservedInstanceResults: []simurator.ReservedInstanceResult{
+					ri("ri-1", "m5.xlarge", time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)),
+					ri("ri-2", "c5.large", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)),
+					ri("ri-3", "c5.large", time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)),
+				},
+			}
+			sorter, err := parseSort(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sorter.SortResults(results)
+			for n, id := range tt.want {
+				if got := *results.UnmatchReservedInstanceResults[n].ReservedInstancesId; got != id {
+					t.Errorf("SortResults() sorted[%d] = %v, want %v", n, got, id)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderBy_rows(t *testing.T) {
+	rows := []AccountSummary{
+		{Account: "222222222222", Covered: 1, Not: 2},
+		{Account: "111111111111", Covered: 1, Not: 2},
+		{Account: "333333333333", Covered: 3},
+	}
+	OrderBy(
+		descending(func(a1, a2 AccountSummary) bool { return a1.Covered < a2.Covered }),
+		func(a1, a2 AccountSummary) bool { return a1.Account < a2.Account },
+	).Sort(rows)
+	want := []string{"333333333333", "111111111111", "222222222222"}
+	for n, account := range want {
+		if rows[n].Account != account {
+			t.Errorf("OrderBy().Sort() rows[%d] = %v, want %v", n, rows[n].Account, account)
+		}
+	}
+}
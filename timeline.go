@@ -0,0 +1,265 @@
+package main
+
+// Expiration timeline: the region is simulated again at checkpoints in the
+// future, dropping the RIs expired by then, to plan renewals.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// TimelineCheckpoint is the simulation of a region at Date
+type TimelineCheckpoint struct {
+	Date time.Time `json:"date"`
+	// number of RIs still active at Date
+	ReservedInstances int `json:"reserved_instances"`
+	// RIs expired since the previous checkpoint
+	ExpiredReservedInstances []string      `json:"expired_reserved_instances"`
+	Summary                  WhatIfSummary `json:"summary"`
+	// running instances partially or not covered at Date
+	UncoveredInstances []JSONInstance `json:"uncovered_instances"`
+	// running instances with less covered units than at the previous checkpoint
+	LostCoverageInstances []TimelineLostCoverage `json:"lost_coverage_instances"`
+}
+
+// TimelineLostCoverage is a running instance losing covered units at a checkpoint
+type TimelineLostCoverage struct {
+	JSONInstance
+	// covered units at the previous checkpoint
+	PreviousCoveredUnits float64 `json:"previous_covered_units"`
+}
+
+type TimelineRegionReport struct {
+	Region      string               `json:"region"`
+	Error       string               `json:"error,omitempty"`
+	Checkpoints []TimelineCheckpoint `json:"checkpoints"`
+}
+
+// TimelineSchemaVersion is incremented on incompatible changes of TimelineReport
+const TimelineSchemaVersion = 1
+
+// TimelineReport is the document of "timeline -output json"
+type TimelineReport struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Regions       []TimelineRegionReport `json:"regions"`
+}
+
+// timelineDates returns start and the checkpoints until months after start
+func timelineDates(start time.Time, months int, interval period) []time.Time {
+	end := start.AddDate(0, months, 0)
+	var dates []time.Time
+	for k := 0; ; k++ {
+		date := interval.after(start, k)
+		if date.After(end) {
+			return dates
+		}
+		dates = append(dates, date)
+	}
+}
+
+// activeReservedInstances returns the RIs not expired at date
+func activeReservedInstances(ris []types.ReservedInstances, date time.Time) []types.ReservedInstances {
+	active := make([]types.ReservedInstances, 0, len(ris))
+	for _, ri := range ris {
+		if ri.End != nil && !ri.End.After(date) {
+			continue
+		}
+		active = append(active, ri)
+	}
+	return active
+}
+
+// uncoveredRunningInstances returns the running instances partially or not covered
+func uncoveredRunningInstances(results simurator.SimulatorResult) []JSONInstance {
+	instances := []JSONInstance{}
+	for _, i := range results.PartialMatchInstanceResults {
+		instances = append(instances, toJSONInstance(i.Instance, i.Units, i.CoveredUnits))
+	}
+	for _, i := range results.UnmatchInstanceResults {
+		if i.State == nil || i.State.Name != types.InstanceStateNameRunning {
+			continue
+		}
+		units := simurator.Units(i.InstanceType)
+		instances = append(instances, toJSONInstance(i, units, 0))
+	}
+	return instances
+}
+
+// coveredUnits returns the covered units of each running instance
+func coveredUnits(results simurator.SimulatorResult) map[string]float64 {
+	covered := map[string]float64{}
+	for _, i := range results.MatchInstanceResults {
+		covered[aws.ToString(i.InstanceId)] = simurator.Units(i.InstanceType)
+	}
+	for _, i := range results.PartialMatchInstanceResults {
+		covered[aws.ToString(i.InstanceId)] = i.CoveredUnits
+	}
+	for _, i := range results.UnmatchInstanceResults {
+		if i.State != nil && i.State.Name == types.InstanceStateNameRunning {
+			covered[aws.ToString(i.InstanceId)] = 0
+		}
+	}
+	return covered
+}
+
+// simulateTimeline simulates the region at each date with the RIs active then
+func simulateTimeline(r RegionResult, dates []time.Time) TimelineRegionReport {
+	report := TimelineRegionReport{Region: r.Region, Checkpoints: []TimelineCheckpoint{}}
+	if r.Err != nil {
+		report.Error = r.Err.Error()
+		return report
+	}
+	if r.Simulator == nil {
+		return report
+	}
+
+	// RIs and covered units of instances at the previous checkpoint
+	var previous []types.ReservedInstances
+	var covered map[string]float64
+	for n, date := range dates {
+		sim := *r.Simulator
+		sim.ReservedInstances = activeReservedInstances(r.Simulator.ReservedInstances, date)
+		results, err := sim.Simulate()
+		if err != nil {
+			report.Error = err.Error()
+			return report
+		}
+
+		checkpoint := TimelineCheckpoint{
+			Date:                     date,
+			ReservedInstances:        len(sim.ReservedInstances),
+			ExpiredReservedInstances: []string{},
+			Summary:                  summarizeWhatIf(results),
+			UncoveredInstances:       uncoveredRunningInstances(results),
+			LostCoverageInstances:    []TimelineLostCoverage{},
+		}
+		for _, ri := range previous {
+			if ri.End != nil && !ri.End.After(date) {
+				checkpoint.ExpiredReservedInstances = append(checkpoint.ExpiredReservedInstances, aws.ToString(ri.ReservedInstancesId))
+			}
+		}
+		// the first checkpoint is the baseline of the timeline; fully
+		// covered instances have lost nothing, so uncovered ones are checked
+		for _, i := range checkpoint.UncoveredInstances {
+			if units, ok := covered[i.InstanceId]; n > 0 && ok && i.CoveredUnits < units {
+				checkpoint.LostCoverageInstances = append(checkpoint.LostCoverageInstances,
+					TimelineLostCoverage{JSONInstance: i, PreviousCoveredUnits: units})
+			}
+		}
+		report.Checkpoints = append(report.Checkpoints, checkpoint)
+		previous, covered = sim.ReservedInstances, coveredUnits(results)
+	}
+	return report
+}
+
+func writeTimelineText(w io.Writer, report TimelineReport) {
+	first := true
+	for _, region := range report.Regions {
+		if region.Error != "" {
+			// the error itself is reported on errStream
+			continue
+		}
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+
+		fmt.Fprintf(w, "=== RI expiration timeline: %s ===\n", regionName(region.Region))
+		fmt.Fprintf(w, "%-10s %5s %9s %8s %10s %12s  %s\n",
+			"Date", "RIs", "Coverage", "Covered", "Partially", "Not covered", "Expired RIs")
+		for _, c := range region.Checkpoints {
+			line := fmt.Sprintf("%-10s %5d %9s %8d %10d %12d  %s",
+				c.Date.Format("2006-01-02"),
+				c.ReservedInstances,
+				formatPercent(c.Summary.Coverage),
+				c.Summary.Covered,
+				c.Summary.PartiallyCovered,
+				c.Summary.Uncovered,
+				strings.Join(c.ExpiredReservedInstances, ", "))
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+		}
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "=== Running instances losing RI coverage ===")
+		for _, c := range region.Checkpoints {
+			for _, i := range c.LostCoverageInstances {
+				fmt.Fprintf(w, "%-10s %-20s %-12s %-20s %6.2f -> %6.2f/%.2f\n",
+					c.Date.Format("2006-01-02"),
+					i.InstanceId,
+					i.InstanceType,
+					i.Name,
+					i.PreviousCoveredUnits,
+					i.CoveredUnits,
+					i.Units)
+			}
+		}
+	}
+}
+
+// runTimeline is the "timeline" subcommand
+func (cli *CLI) runTimeline(args []string) int {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(cli.errStream)
+	var input inputFlags
+	input.register(flags)
+	months := flags.Int("months", 12, "simulate `n` months ahead")
+	intervalFlag := flags.String("interval", "1m", "`interval` of checkpoints: months (1m), weeks (2w) or days (10d)")
+	startFlag := flags.String("start", "", "first checkpoint as `YYYY-MM-DD` (default: today)")
+	output := flags.String("output", "text", "output `format`: text or json")
+	if code, ok := parseFlags(flags, args[1:]); !ok {
+		return code
+	}
+
+	if *months <= 0 {
+		fmt.Fprintf(cli.errStream, "invalid -months: %d\n", *months)
+		return ExitCodeError
+	}
+	interval, err := parsePeriod("interval", *intervalFlag)
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	start, err := parseDate("start", *startFlag, time.Now())
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	if err := validateOutput(*output); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	if err := input.validate(); err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+
+	regionResults, err := input.simulate()
+	if err != nil {
+		fmt.Fprintln(cli.errStream, err.Error())
+		return ExitCodeError
+	}
+	exitCode := cli.reportErrors(regionResults)
+
+	dates := timelineDates(start, *months, interval)
+	report := TimelineReport{SchemaVersion: TimelineSchemaVersion, Regions: []TimelineRegionReport{}}
+	for _, r := range regionResults {
+		report.Regions = append(report.Regions, simulateTimeline(r, dates))
+	}
+
+	if *output == "json" {
+		if err := writeJSON(cli.outStream, report); err != nil {
+			fmt.Fprintln(cli.errStream, err.Error())
+			return ExitCodeError
+		}
+	} else {
+		writeTimelineText(cli.outStream, report)
+	}
+	return exitCode
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+func Test_timelineDates(t *testing.T) {
+	start := time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC)
+	date := func(m time.Month, d int) time.Time { return time.Date(2022, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		months   int
+		interval period
+		want     []time.Time
+	}{
+		{
+			name:     "monthly",
+			months:   3,
+			interval: period{n: 1, unit: 'm'},
+			// months are added to start, as time.AddDate normalizes Feb 31
+			want: []time.Time{start, date(3, 3), date(3, 31), date(5, 1)},
+		},
+		{
+			name:     "every 2 weeks",
+			months:   1,
+			interval: period{n: 2, unit: 'w'},
+			want:     []time.Time{start, date(2, 14), date(2, 28)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timelineDates(start, tt.months, tt.interval); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("timelineDates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_simulateTimeline(t *testing.T) {
+	running := &types.InstanceState{Name: types.InstanceStateNameRunning}
+	ri := func(id string, end time.Time) types.ReservedInstances {
+		return types.ReservedInstances{
+			ReservedInstancesId: aws.String(id),
+			InstanceType:        "m5.large",
+			InstanceCount:       aws.Int32(1),
+			ProductDescription:  "Linux/UNIX",
+			Scope:               types.ScopeRegional,
+			End:                 aws.Time(end),
+		}
+	}
+	sim := &simurator.Simulator{
+		Instances: []types.Instance{
+			{InstanceId: aws.String("i-1"), InstanceType: "m5.xlarge", State: running},
+			// never covered, so never losing coverage
+			{InstanceId: aws.String("i-2"), InstanceType: "t3.medium", Platform: "windows", State: running},
+		},
+		ReservedInstances: []types.ReservedInstances{
+			ri("ri-1", time.Date(2022, 2, 15, 0, 0, 0, 0, time.UTC)),
+			ri("ri-2", time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	results, err := sim.Simulate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dates := []time.Time{
+		time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 2, 20, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	report := simulateTimeline(RegionResult{Region: "ap-northeast-1", Results: results, Simulator: sim}, dates)
+
+	type lost struct {
+		id       string
+		previous float64
+		covered  float64
+	}
+	type checkpoint struct {
+		ris      int
+		expired  []string
+		coverage float64
+		lost     []lost
+	}
+	want := []checkpoint{
+		{ris: 2, expired: []string{}, coverage: 80, lost: []lost{}},
+		{ris: 2, expired: []string{}, coverage: 80, lost: []lost{}},
+		// a partially covered instance loses coverage as well
+		{ris: 1, expired: []string{"ri-1"}, coverage: 40, lost: []lost{{"i-1", 8, 4}}},
+		// RIs ended at the checkpoint are expired
+		{ris: 0, expired: []string{"ri-2"}, coverage: 0, lost: []lost{{"i-1", 4, 0}}},
+	}
+	if len(report.Checkpoints) != len(want) {
+		t.Fatalf("simulateTimeline() = %d checkpoints, want %d", len(report.Checkpoints), len(want))
+	}
+	for n, c := range report.Checkpoints {
+		got := checkpoint{c.ReservedInstances, c.ExpiredReservedInstances, c.Summary.Coverage, []lost{}}
+		for _, i := range c.LostCoverageInstances {
+			got.lost = append(got.lost, lost{i.InstanceId, i.PreviousCoveredUnits, i.CoveredUnits})
+		}
+		if !reflect.DeepEqual(got, want[n]) {
+			t.Errorf("simulateTimeline() checkpoint %s = %+v, want %+v", c.Date.Format("2006-01-02"), got, want[n])
+		}
+	}
+}
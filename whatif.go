@@ -0,0 +1,288 @@
+package main
+
+// What-if simulation: hypothetical RIs are added to the real ones of each
+// region and the result is compared with the baseline simulation.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	simurator "github.com/ueki-kazuki/gori-simulator/simulator"
+)
+
+// HypotheticalRI is a reservation that is not purchased yet.
+// Without Region and AvailabilityZone it is added to the simulated region,
+// which must be the only one.
+type HypotheticalRI struct {
+	InstanceCount      int32  `json:"instance_count"`
+	InstanceType       string `json:"instance_type"`
+	ProductDescription string `json:"product_description"`
+	// regional RI of the region
+	Region string `json:"region,omitempty"`
+	// zonal RI of the Availability Zone
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	// "default" if empty
+	Tenancy string `json:"tenancy,omitempty"`
+}
+
+// Scenario is the file of -scenario
+type Scenario struct {
+	ReservedInstances []HypotheticalRI `json:"reserved_instances"`
+}
+
+// HypotheticalRIs is the value of the repeatable -add-ri flag
+type HypotheticalRIs []HypotheticalRI
+
+func (h *HypotheticalRIs) String() string {
+	specs := make([]string, 0, len(*h))
+	for _, ri := range *h {
+		specs = append(specs, ri.String())
+	}
+	return strings.Join(specs, ", ")
+}
+
+func (h *HypotheticalRIs) Set(spec string) error {
+	ri, err := parseHypotheticalRI(spec)
+	if err != nil {
+		return err
+	}
+	*h = append(*h, ri)
+	return nil
+}
+
+// String formats the RI as "3x m5.large Linux/UNIX (ap-northeast-1a)"
+func (ri HypotheticalRI) String() string {
+	scope := string(types.ScopeRegional)
+	if ri.AvailabilityZone != "" {
+		scope = ri.AvailabilityZone
+	} else if ri.Region != "" {
+		scope = ri.Region
+	}
+	return fmt.Sprintf("%dx %s %s (%s)", ri.InstanceCount, ri.InstanceType, ri.ProductDescription, scope)
+}
+
+// parseHypotheticalRI parses "count:type:product[:az or region]",
+// e.g. "3:m5.large:Linux/UNIX" or "1:c5.xlarge:Windows:ap-northeast-1a".
+func parseHypotheticalRI(spec string) (HypotheticalRI, error) {
+	fields := strings.Split(spec, ":")
+	if len(fields) < 3 || len(fields) > 4 {
+		return HypotheticalRI{}, fmt.Errorf("invalid RI %q: want count:type:product[:az or region]", spec)
+	}
+	count, err := strconv.Atoi(fields[0])
+	if err != nil || count <= 0 {
+		return HypotheticalRI{}, fmt.Errorf("invalid RI %q: count must be a positive number", spec)
+	}
+	ri := HypotheticalRI{
+		InstanceCount:      int32(count),
+		InstanceType:       fields[1],
+		ProductDescription: fields[2],
+	}
+	if len(fields) == 4 {
+		if isAvailabilityZone(fields[3]) {
+			ri.AvailabilityZone = fields[3]
+		} else {
+			ri.Region = fields[3]
+		}
+	}
+	return ri, ri.validate()
+}
+
+func (ri HypotheticalRI) validate() error {
+	if ri.InstanceCount <= 0 {
+		return fmt.Errorf("invalid RI %s: instance_count must be positive", ri)
+	}
+	// the size must be in the normalization table
+	family, _ := simurator.SplitInstanceType(types.InstanceType(ri.InstanceType))
+	if _, ok := simurator.NormalizationFactor(types.InstanceType(ri.InstanceType)); family == "" || !ok {
+		return fmt.Errorf("invalid RI %s: unknown instance type %q", ri, ri.InstanceType)
+	}
+	if ri.ProductDescription == "" {
+		return fmt.Errorf("invalid RI %s: product_description is required", ri)
+	}
+	if !simurator.IsProduct(ri.ProductDescription) {
+		return fmt.Errorf("invalid RI %s: unknown product_description %q", ri, ri.ProductDescription)
+	}
+	if ri.Tenancy != "" && ri.Tenancy != string(types.TenancyDefault) && ri.Tenancy != string(types.TenancyDedicated) {
+		return fmt.Errorf("invalid RI %s: tenancy must be default or dedicated", ri)
+	}
+	return nil
+}
+
+// isAvailabilityZone reports whether s is an AZ ("ap-northeast-1a")
+// rather than a region ("ap-northeast-1")
+func isAvailabilityZone(s string) bool {
+	return s != "" && s[len(s)-1] >= 'a' && s[len(s)-1] <= 'z'
+}
+
+// regionOf returns the region of the RI, or "" if it applies to the simulated region
+func (ri HypotheticalRI) regionOf() string {
+	if ri.AvailabilityZone != "" {
+		return zoneRegion(ri.AvailabilityZone)
+	}
+	return ri.Region
+}
+
+// zoneRegion returns the region of an availability zone like "ap-northeast-1a"
+func zoneRegion(zone string) string {
+	return strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz")
+}
+
+// instancesRegion derives the region from the availability zones of
+// instances, for offline input without a region. It returns "" if unknown.
+func instancesRegion(instances []types.Instance) string {
+	for _, i := range instances {
+		if i.Placement != nil && aws.ToString(i.Placement.AvailabilityZone) != "" {
+			return zoneRegion(aws.ToString(i.Placement.AvailabilityZone))
+		}
+	}
+	return ""
+}
+
+func readScenario(r io.Reader) ([]HypotheticalRI, error) {
+	var scenario Scenario
+	if err := json.NewDecoder(r).Decode(&scenario); err != nil {
+		return nil, err
+	}
+	for _, ri := range scenario.ReservedInstances {
+		if err := ri.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return scenario.ReservedInstances, nil
+}
+
+func loadScenario(path string) ([]HypotheticalRI, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readScenario(f)
+}
+
+// checkWhatIfRegions requires the region of the hypothetical RIs when
+// several regions are simulated, as an RI is purchased in one region only
+func checkWhatIfRegions(ris []HypotheticalRI, regionResults []RegionResult) error {
+	if len(regionResults) <= 1 {
+		return nil
+	}
+	for _, ri := range ris {
+		if ri.regionOf() == "" {
+			return fmt.Errorf("invalid RI %s: region or availability zone is required with several regions", ri)
+		}
+	}
+	return nil
+}
+
+// toReservedInstances returns the hypothetical RIs applicable in the region
+// as active reservations with IDs "what-if-1", "what-if-2", ...
+// Region-scoped RIs are skipped if the region is unknown.
+func toReservedInstances(ris []HypotheticalRI, region string) []types.ReservedInstances {
+	reservedInstances := make([]types.ReservedInstances, 0, len(ris))
+	for n, ri := range ris {
+		if r := ri.regionOf(); r != "" && r != region {
+			continue
+		}
+		reservedInstance := types.ReservedInstances{
+			ReservedInstancesId: aws.String(fmt.Sprintf("what-if-%d", n+1)),
+			InstanceCount:       aws.Int32(ri.InstanceCount),
+			InstanceType:        types.InstanceType(ri.InstanceType),
+			ProductDescription:  types.RIProductDescription(ri.ProductDescription),
+			InstanceTenancy:     types.Tenancy(ri.Tenancy),
+			Scope:               types.ScopeRegional,
+			State:               types.ReservedInstanceStateActive,
+		}
+		if ri.AvailabilityZone != "" {
+			reservedInstance.Scope = types.ScopeAvailabilityZone
+			reservedInstance.AvailabilityZone = aws.String(ri.AvailabilityZone)
+		}
+		reservedInstances = append(reservedInstances, reservedInstance)
+	}
+	return reservedInstances
+}
+
+// simulateWhatIf simulates the region again with the hypothetical RIs added.
+// Results become the what-if results and Baseline the original ones.
+func simulateWhatIf(r RegionResult, ris []HypotheticalRI) RegionResult {
+	if r.Err != nil || r.Simulator == nil {
+		return r
+	}
+	sim := *r.Simulator
+	region := r.Region
+	if region == "" {
+		region = instancesRegion(sim.Instances)
+	}
+	sim.ReservedInstances = append(append([]types.ReservedInstances{}, sim.ReservedInstances...),
+		toReservedInstances(ris, region)...)
+	baseline := r.Results
+	r.Baseline = &baseline
+	r.Results, r.Err = sim.Simulate()
+	return r
+}
+
+// WhatIfSummary is the outcome of a simulation compared by the what-if report
+type WhatIfSummary struct {
+	Covered          int     `json:"covered"`
+	PartiallyCovered int     `json:"partially_covered"`
+	Uncovered        int     `json:"uncovered"`
+	Coverage         float64 `json:"coverage"`
+	UnusedUnits      float64 `json:"unused_units"`
+}
+
+func summarizeWhatIf(results simurator.SimulatorResult) WhatIfSummary {
+	summary := WhatIfSummary{
+		Covered:          len(results.MatchInstanceResults),
+		PartiallyCovered: len(results.PartialMatchInstanceResults),
+		Uncovered:        len(results.UnmatchInstanceResults),
+		Coverage:         results.Statistics().Coverage(),
+	}
+	for _, ri := range results.ReservedInstanceResults {
+		summary.UnusedUnits += ri.RemainingUnits
+	}
+	return summary
+}
+
+// WhatIfDelta compares the baseline and the what-if simulation of a region
+type WhatIfDelta struct {
+	Baseline WhatIfSummary `json:"baseline"`
+	WhatIf   WhatIfSummary `json:"what_if"`
+}
+
+// whatIfDelta returns nil unless the region was simulated with what-if RIs
+func whatIfDelta(r RegionResult) *WhatIfDelta {
+	if r.Baseline == nil || r.Err != nil {
+		return nil
+	}
+	return &WhatIfDelta{
+		Baseline: summarizeWhatIf(*r.Baseline),
+		WhatIf:   summarizeWhatIf(r.Results),
+	}
+}
+
+// whatIfRow is a formatted row of the what-if report
+type whatIfRow struct {
+	Label   string
+	Current string
+	WhatIf  string
+	Delta   string
+}
+
+func whatIfRows(delta WhatIfDelta) []whatIfRow {
+	b, a := delta.Baseline, delta.WhatIf
+	count := func(label string, b, a int) whatIfRow {
+		return whatIfRow{label, strconv.Itoa(b), strconv.Itoa(a), fmt.Sprintf("%+d", a-b)}
+	}
+	return []whatIfRow{
+		count("Covered", b.Covered, a.Covered),
+		count("Partially covered", b.PartiallyCovered, a.PartiallyCovered),
+		count("Not covered", b.Uncovered, a.Uncovered),
+		{"Coverage", formatPercent(b.Coverage), formatPercent(a.Coverage), fmt.Sprintf("%+.1f%%", a.Coverage-b.Coverage)},
+		{"Unused units", formatUnits(b.UnusedUnits), formatUnits(a.UnusedUnits), fmt.Sprintf("%+.2f", a.UnusedUnits-b.UnusedUnits)},
+	}
+}
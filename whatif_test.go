@@ -0,0 +1,224 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func Test_parseHypotheticalRI(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    HypotheticalRI
+		wantErr bool
+	}{
+		{
+			spec: "3:m5.large:Linux/UNIX",
+			want: HypotheticalRI{InstanceCount: 3, InstanceType: "m5.large", ProductDescription: "Linux/UNIX"},
+		},
+		{
+			spec: "1:c5.xlarge:Windows:ap-northeast-1a",
+			want: HypotheticalRI{InstanceCount: 1, InstanceType: "c5.xlarge", ProductDescription: "Windows", AvailabilityZone: "ap-northeast-1a"},
+		},
+		{
+			spec: "2:r5.large:Red Hat Enterprise Linux:us-east-1",
+			want: HypotheticalRI{InstanceCount: 2, InstanceType: "r5.large", ProductDescription: "Red Hat Enterprise Linux", Region: "us-east-1"},
+		},
+		{spec: "m5.large:Linux/UNIX", wantErr: true},
+		{spec: "0:m5.large:Linux/UNIX", wantErr: true},
+		{spec: "x:m5.large:Linux/UNIX", wantErr: true},
+		{spec: "1:m5:Linux/UNIX", wantErr: true},
+		{spec: "1:m5.large:", wantErr: true},
+		{spec: "1:m5.lage:Linux/UNIX", wantErr: true},
+		{spec: "1:m5.large:linux", wantErr: true},
+		{
+			spec: "1:m5.metal:Linux/UNIX (Amazon VPC)",
+			want: HypotheticalRI{InstanceCount: 1, InstanceType: "m5.metal", ProductDescription: "Linux/UNIX (Amazon VPC)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseHypotheticalRI(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseHypotheticalRI() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseHypotheticalRI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readScenario(t *testing.T) {
+	got, err := loadScenario("testdata/scenario.json")
+	if err != nil {
+		t.Fatalf("loadScenario() error = %v", err)
+	}
+	want := []HypotheticalRI{
+		{InstanceCount: 1, InstanceType: "t3.medium", ProductDescription: "Windows"},
+		{InstanceCount: 1, InstanceType: "c5.xlarge", ProductDescription: "Linux/UNIX", AvailabilityZone: "us-east-1a"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadScenario() = %v, want %v", got, want)
+	}
+
+	_, err = readScenario(strings.NewReader(`{"reserved_instances":[{"instance_count":1,"instance_type":"m5.large","product_description":"Linux/UNIX","tenancy":"host"}]}`))
+	if err == nil {
+		t.Errorf("readScenario() error = nil, want invalid tenancy")
+	}
+}
+
+func Test_toReservedInstances(t *testing.T) {
+	ris := []HypotheticalRI{
+		{InstanceCount: 3, InstanceType: "m5.large", ProductDescription: "Linux/UNIX"},
+		{InstanceCount: 1, InstanceType: "c5.xlarge", ProductDescription: "Windows", AvailabilityZone: "ap-northeast-1a"},
+		{InstanceCount: 1, InstanceType: "r5.large", ProductDescription: "Linux/UNIX", Region: "us-east-1", Tenancy: "dedicated"},
+	}
+	ids := func(ris []types.ReservedInstances) []string {
+		ids := make([]string, 0)
+		for _, ri := range ris {
+			ids = append(ids, aws.ToString(ri.ReservedInstancesId))
+		}
+		return ids
+	}
+	tests := []struct {
+		region string
+		want   []string
+	}{
+		{region: "ap-northeast-1", want: []string{"what-if-1", "what-if-2"}},
+		{region: "us-east-1", want: []string{"what-if-1", "what-if-3"}},
+		// region-scoped RIs are not applicable in an unknown region
+		{region: "", want: []string{"what-if-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			if got := ids(toReservedInstances(ris, tt.region)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toReservedInstances() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	got := toReservedInstances(ris, "ap-northeast-1")
+	if got[1].Scope != types.ScopeAvailabilityZone || aws.ToString(got[1].AvailabilityZone) != "ap-northeast-1a" {
+		t.Errorf("toReservedInstances()[1] = %v, want zonal RI in ap-northeast-1a", got[1])
+	}
+	if got[0].Scope != types.ScopeRegional {
+		t.Errorf("toReservedInstances()[0] = %v, want regional RI", got[0])
+	}
+	if got := toReservedInstances(ris, "us-east-1"); got[1].InstanceTenancy != types.TenancyDedicated {
+		t.Errorf("toReservedInstances()[1] = %v, want dedicated RI", got[1])
+	}
+}
+
+func Test_checkWhatIfRegions(t *testing.T) {
+	regional := HypotheticalRI{InstanceCount: 1, InstanceType: "m5.large", ProductDescription: "Linux/UNIX"}
+	inRegion := HypotheticalRI{InstanceCount: 1, InstanceType: "m5.large", ProductDescription: "Linux/UNIX", Region: "us-east-1"}
+	inZone := HypotheticalRI{InstanceCount: 1, InstanceType: "m5.large", ProductDescription: "Linux/UNIX", AvailabilityZone: "us-east-1a"}
+	oneRegion := []RegionResult{{Region: "us-east-1"}}
+	twoRegions := []RegionResult{{Region: "us-east-1"}, {Region: "ap-northeast-1"}}
+	tests := []struct {
+		name          string
+		ris           []HypotheticalRI
+		regionResults []RegionResult
+		wantErr       bool
+	}{
+		{name: "one region", ris: []HypotheticalRI{regional}, regionResults: oneRegion},
+		{name: "several regions", ris: []HypotheticalRI{inRegion, inZone}, regionResults: twoRegions},
+		{name: "several regions without region", ris: []HypotheticalRI{inRegion, regional}, regionResults: twoRegions, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkWhatIfRegions(tt.ris, tt.regionResults); (err != nil) != tt.wantErr {
+				t.Errorf("checkWhatIfRegions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_instancesRegion(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances []types.Instance
+		want      string
+	}{
+		{
+			name: "availability zone",
+			instances: []types.Instance{
+				{InstanceId: aws.String("i-1")},
+				{InstanceId: aws.String("i-2"), Placement: &types.Placement{AvailabilityZone: aws.String("eu-west-1b")}},
+			},
+			want: "eu-west-1",
+		},
+		{
+			name:      "unknown",
+			instances: []types.Instance{{InstanceId: aws.String("i-1"), Placement: &types.Placement{}}},
+			want:      "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := instancesRegion(tt.instances); got != tt.want {
+				t.Errorf("instancesRegion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_simulateWhatIf(t *testing.T) {
+	r := testRegionResults()[0]
+	got := simulateWhatIf(r, []HypotheticalRI{
+		{InstanceCount: 1, InstanceType: "m5.large", ProductDescription: "Linux/UNIX"},
+	})
+	if got.Err != nil {
+		t.Fatalf("simulateWhatIf() error = %v", got.Err)
+	}
+	if len(r.Simulator.ReservedInstances) != 2 {
+		t.Errorf("simulateWhatIf() modified the baseline RIs: %v", r.Simulator.ReservedInstances)
+	}
+	want := WhatIfDelta{
+		Baseline: WhatIfSummary{Covered: 1, PartiallyCovered: 1, Uncovered: 2, Coverage: 16.0 / 22 * 100, UnusedUnits: 4},
+		WhatIf:   WhatIfSummary{Covered: 2, PartiallyCovered: 0, Uncovered: 2, Coverage: 20.0 / 22 * 100, UnusedUnits: 4},
+	}
+	if delta := whatIfDelta(got); delta == nil || !reflect.DeepEqual(*delta, want) {
+		t.Errorf("whatIfDelta() = %v, want %v", delta, want)
+	}
+	if delta := whatIfDelta(r); delta != nil {
+		t.Errorf("whatIfDelta() = %v, want nil without what-if RIs", delta)
+	}
+}
+
+func Test_simulateWhatIf_offline(t *testing.T) {
+	// offline input has no region; it is derived from the instances
+	r := simulateFiles("testdata/describe-instances.json", "testdata/describe-reserved-instances.json")
+	tests := []struct {
+		name string
+		ri   HypotheticalRI
+		want float64
+	}{
+		{
+			name: "same region",
+			ri:   HypotheticalRI{InstanceCount: 1, InstanceType: "m5.large", ProductDescription: "Linux/UNIX", Region: "ap-northeast-1"},
+			want: 20.0 / 22 * 100,
+		},
+		{
+			name: "other region",
+			ri:   HypotheticalRI{InstanceCount: 1, InstanceType: "m5.large", ProductDescription: "Linux/UNIX", Region: "eu-west-1"},
+			want: 16.0 / 22 * 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := simulateWhatIf(r, []HypotheticalRI{tt.ri})
+			if got.Err != nil {
+				t.Fatalf("simulateWhatIf() error = %v", got.Err)
+			}
+			if coverage := got.Results.Statistics().Coverage(); coverage != tt.want {
+				t.Errorf("coverage = %v, want %v", coverage, tt.want)
+			}
+		})
+	}
+}